@@ -27,7 +27,15 @@ func main() {
 	exit := make(chan os.Signal, 1)
 	signal.Notify(exit, os.Interrupt, syscall.SIGTERM)
 
-	opts := []eventsource.RequestModifier{}
+	opts := []eventsource.Option{
+		eventsource.WithStatusHandler(func(status eventsource.Status) {
+			if status.Err == nil {
+				log.Printf("state=%s\n\n", status.ReadyState)
+			} else {
+				log.Printf("state=%s err=%v\n\n", status.ReadyState, status.Err)
+			}
+		}),
+	}
 	if *username != "" && *password != "" {
 		opts = append(opts, eventsource.WithBasicAuth(*username, *password))
 	}
@@ -45,12 +53,6 @@ func main() {
 		select {
 		case event := <-es.MessageEvents():
 			log.Printf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Name, event.Data)
-		case status := <-es.ReadyState():
-			if status.Err == nil {
-				log.Printf("state=%s\n\n", status.ReadyState)
-			} else {
-				log.Printf("state=%s err=%v\n\n", status.ReadyState, status.Err)
-			}
 		case <-exit:
 			es.Close()
 			return
@@ -28,6 +28,14 @@ type MockHandler struct {
 	// MaxRequestsToProcess before closing the stream.
 	MaxRequestsToProcess int
 
+	// StatusCodes queued to be answered, one per request, before serving
+	// the stream. RetryAfter is sent along them when set.
+	StatusCodes []int
+	RetryAfter  string
+
+	// OnRequest is called with every request received, when set.
+	OnRequest func(req *http.Request)
+
 	// Server requires basic authorization if username is set
 	BasicAuth struct {
 		Username string
@@ -64,6 +72,10 @@ func (h *MockHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	h.encoder = encoder.New(rw)
 	h.flusher = rw.(http.Flusher)
 
+	if h.OnRequest != nil {
+		h.OnRequest(req)
+	}
+
 	if len(h.BasicAuth.Username) > 0 {
 		username, password, ok := req.BasicAuth()
 		if !ok {
@@ -77,6 +89,16 @@ func (h *MockHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	if len(h.StatusCodes) > 0 {
+		code := h.StatusCodes[0]
+		h.StatusCodes = h.StatusCodes[1:]
+		if h.RetryAfter != "" {
+			rw.Header().Set("Retry-After", h.RetryAfter)
+		}
+		http.Error(rw, http.StatusText(code), code)
+		return
+	}
+
 	rw.Header().Set("Connection", "keep-alive")
 	rw.Header().Set("Content-Type", h.ContentType)
 
@@ -96,6 +118,7 @@ func (h *MockHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 	select {
 	case <-h.closer:
+	case <-req.Context().Done():
 	case <-time.After(1 * time.Second):
 		// No test ever should take more than 1 second to run
 		h.t.Log("auto-closing active request after 1s")
@@ -126,6 +149,11 @@ func (h *MockHandler) WriteRetry(delayInMillis int, getDecoder func() *decoder.D
 	})
 }
 
+// ExpectLastEventID sets the Last-Event-ID the next request must provide.
+func (h *MockHandler) ExpectLastEventID(id string) {
+	h.lastEventID = id
+}
+
 // CloseActiveRequest cancels the current request being served
 func (h *MockHandler) CloseActiveRequest(block bool) {
 	h.t.Logf("[closing active request (block=%v)]", block)
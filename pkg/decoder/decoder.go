@@ -3,9 +3,9 @@ package decoder
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"io"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/alevinval/sse/internal"
@@ -16,112 +16,184 @@ import (
 // The spec recommends to use a value of a few seconds.
 const defaultRetry = time.Duration(2500) * time.Millisecond
 
+// Initial size of the buffer of decoders with a growing buffer, same as
+// bufio.Scanner.
+const initialBufferSize = 4096
+
+// Name of events that do not specify one, in spec mode.
+const defaultEventName = "message"
+
+// ErrEventTooLarge means the data of an event exceeds the maximum size,
+// see WithMaxEventSize.
+var ErrEventTooLarge = errors.New("decoder: event exceeds the maximum size")
+
 // Decoder accepts an io.Reader input and decodes message events from it.
 type Decoder struct {
-	scanner *bufio.Scanner
-	data    *bytes.Buffer
-	retry   time.Duration
+	scanner      *bufio.Scanner
+	buf          []byte
+	maxLineSize  int
+	data         *bytes.Buffer
+	id           []byte
+	name         []byte
+	retry        time.Duration
+	maxEventSize int
+	invalidUTF8  InvalidUTF8Handling
+	started      bool
+	err          error
+
+	// state of the event being parsed
+	eventSeen bool
+	hasID     bool
+
+	// data of the current event, see DecodeStream
+	stream *dataReader
+
+	recordHandlers []func(r Record)
+	retryHandlers  []func(change RetryChange)
+
+	// line holds the current line when invalid UTF-8 is replaced
+	line []byte
+
+	// strings of the latest decoded ID and name, reused while they repeat
+	idString   string
+	nameString string
+
+	// spec mode and the last event ID buffer, see WithSpecMode
+	spec        bool
+	lastEventID []byte
+}
+
+// EventView is a view of a decoded event over the buffers of the Decoder,
+// see DecodeView. Its fields are only valid until the next call to the
+// Decoder.
+type EventView struct {
+	ID    []byte
+	Name  []byte
+	Data  []byte
+	HasID bool
 }
 
 // New returns a Decoder with a growing buffer.
 // Lines are limited to bufio.MaxScanTokenSize - 1.
-func New(in io.Reader) *Decoder {
-	return NewSize(in, 0)
+func New(in io.Reader, opts ...Option) *Decoder {
+	return NewSize(in, 0, opts...)
 }
 
 // NewSize returns a Decoder with a fixed buffer size.
-func NewSize(in io.Reader, bufferSize int) *Decoder {
-	d := &Decoder{scanner: bufio.NewScanner(in), data: new(bytes.Buffer), retry: defaultRetry}
+func NewSize(in io.Reader, bufferSize int, opts ...Option) *Decoder {
+	d := &Decoder{data: new(bytes.Buffer), retry: defaultRetry}
 	if bufferSize > 0 {
-		d.scanner.Buffer(make([]byte, bufferSize), bufferSize)
+		d.buf, d.maxLineSize = make([]byte, bufferSize), bufferSize
+	} else {
+		d.buf, d.maxLineSize = make([]byte, initialBufferSize), bufio.MaxScanTokenSize
+	}
+	d.newScanner(in)
+	for _, opt := range opts {
+		opt(d)
 	}
-	d.scanner.Split(internal.ScanLinesCR) // See scanlines.go
 	return d
 }
 
+// Reset discards the state of the current input and decodes from in
+// instead. The event buffers, the reconnection time and the last event ID
+// buffer are kept, as the spec requires when reconnecting. The line buffer
+// starts again from its initial size, whatever it grew to for long lines.
+func (d *Decoder) Reset(in io.Reader) {
+	d.newScanner(in)
+	d.reset()
+	d.started, d.stream, d.err = false, nil, nil
+}
+
+func (d *Decoder) newScanner(in io.Reader) {
+	d.scanner = bufio.NewScanner(in)
+	d.scanner.Buffer(d.buf, d.maxLineSize)
+	d.scanner.Split(internal.ScanLinesCR) // See scanlines.go
+}
+
 // Retry returns the to wait before attempting to reconnect to the event source.
 func (d *Decoder) Retry() time.Duration {
 	return d.retry
 }
 
+// LastEventID returns the last event ID buffer, which holds the value of the
+// last id field decoded.
+func (d *Decoder) LastEventID() string {
+	return string(d.lastEventID)
+}
+
+// SpecMode returns true when the decoder follows the HTML specification, see
+// WithSpecMode.
+func (d *Decoder) SpecMode() bool {
+	return d.spec
+}
+
 // Decode reads the input stream and parses events from it.
-// Any error while reading is  returned.
+// Any error while reading is returned, such as bufio.ErrTooLong when a line
+// does not fit in the buffer, or io.EOF at the end of the input. Once an
+// error is returned, the following calls return it as well.
 func (d *Decoder) Decode() (*base.MessageEvent, error) {
-	// Stores event data, which is filled after one or many lines
-	// from the reader
-	var id, name, value, fieldName string
-	var eventSeen, hasID bool
+	ev := new(base.MessageEvent)
+	if err := d.DecodeInto(ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
 
-	d.data.Reset()
-	for d.scanner.Scan() {
-		line := d.scanner.Text()
-
-		// Empty line? => Dispatch event
-		if len(line) == 0 {
-			if eventSeen {
-
-				// Trim the last LF
-				if l := d.data.Len(); l > 0 {
-					d.data.Truncate(l - 1)
-				}
-
-				// Note the event source spec as defined by w3.org requires
-				// skips the event dispatching if the event name collides with
-				// the name of any event as defined in the DOM Events spec.
-				// Decoder does not perform this check, hence it could yield
-				// events that would not be valid in a browser.
-				return &base.MessageEvent{
-					ID:    id,
-					Name:  name,
-					Data:  d.data.String(),
-					HasID: hasID,
-				}, nil
-			}
-
-			continue
-		}
+// DecodeInto works like Decode, but it reuses the given event, as well as
+// the strings of IDs and names that repeat from the previous event.
+func (d *Decoder) DecodeInto(ev *base.MessageEvent) error {
+	if _, err := d.decode(); err != nil {
+		return err
+	}
+	d.fill(ev)
+	return nil
+}
 
-		colonIndex := strings.IndexByte(line, ':')
-		if colonIndex == 0 {
-			// Skip comment
-			continue
-		}
+// fill sets the fields of the event from the buffers of the decoder.
+func (d *Decoder) fill(ev *base.MessageEvent) {
+	if string(d.id) != d.idString {
+		d.idString = string(d.id)
+	}
+	if string(d.name) != d.nameString {
+		d.nameString = string(d.name)
+	}
+	ev.ID = d.idString
+	ev.Name = d.nameString
+	ev.Data = d.data.String()
+	ev.HasID = d.hasID
+}
 
-		if colonIndex == -1 {
-			fieldName = line
-			value = ""
-		} else {
-			// Extract key/value for current line
-			fieldName = line[:colonIndex]
-			if colonIndex < len(line)-1 && line[colonIndex+1] == ' ' {
-				// Trim prefix space
-				value = line[colonIndex+2:]
-			} else {
-				value = line[colonIndex+1:]
-			}
-		}
+// DecodeView works like Decode, but it does not allocate, the view points
+// to the buffers of the Decoder, which are overwritten by the next call.
+func (d *Decoder) DecodeView(ev *EventView) error {
+	hasID, err := d.decode()
+	if err != nil {
+		return err
+	}
+
+	ev.ID = d.id
+	ev.Name = d.name
+	ev.Data = d.data.Bytes()
+	ev.HasID = hasID
+	return nil
+}
 
-		switch fieldName {
-		case "event":
-			name = value
-			eventSeen = true
-		case "data":
-			d.data.WriteString(value)
-			d.data.WriteByte('\n')
-			eventSeen = true
-		case "id":
-			if !strings.ContainsAny(value, "\u0000") {
-				id = value
-				eventSeen = true
-				hasID = true
-			}
-		case "retry":
-			retry, err := strconv.Atoi(value)
-			if err == nil && retry >= 0 {
-				d.retry = time.Duration(retry) * time.Millisecond
-			}
-		default:
-			// Ignore field
+// decode parses the next event into the buffers of the decoder.
+func (d *Decoder) decode() (hasID bool, err error) {
+	d.discardStream()
+	if d.err != nil {
+		return false, d.err
+	}
+
+	d.reset()
+	for d.scanner.Scan() {
+		dispatch, err := d.parseLine(d.scanner.Bytes())
+		if err != nil {
+			d.err = err
+			return false, err
+		}
+		if dispatch {
+			return d.hasID, nil
 		}
 	}
 
@@ -129,5 +201,127 @@ func (d *Decoder) Decode() (*base.MessageEvent, error) {
 	// "Once the end of the file is reached, any pending data must be
 	//  discarded. (If the file ends in the middle of an event, before the final
 	//  empty line, the incomplete event is not dispatched.)"
-	return nil, io.EOF
+	d.err = d.scanner.Err()
+	if d.err == nil {
+		d.err = io.EOF
+	}
+	return false, d.err
+}
+
+// reset discards the event being parsed.
+func (d *Decoder) reset() {
+	d.id = d.id[:0]
+	d.name = d.name[:0]
+	d.data.Reset()
+	d.eventSeen, d.hasID = false, false
+}
+
+// parseLine processes a line of the stream, it returns true when the event
+// must be dispatched.
+func (d *Decoder) parseLine(line []byte) (dispatch bool, err error) {
+	line, err = d.readLine(line)
+	if err != nil {
+		return false, err
+	}
+
+	// Empty line? => Dispatch event
+	if len(line) == 0 {
+		if !d.complete(d.data.Len() > 0) {
+			return false, nil
+		}
+
+		// Trim the last LF
+		if l := d.data.Len(); l > 0 {
+			d.data.Truncate(l - 1)
+		}
+		return true, nil
+	}
+
+	fieldName, value, ok := d.splitField(line)
+	if !ok {
+		// Skip comment
+		return false, nil
+	}
+	return false, d.parseField(fieldName, value)
+}
+
+// complete is called at the end of an event, it returns false when there is
+// no event to dispatch.
+func (d *Decoder) complete(hasData bool) bool {
+	if !d.eventSeen {
+		return false
+	}
+
+	if d.spec {
+		if !hasData {
+			// Events without data are not dispatched in spec mode
+			d.reset()
+			return false
+		}
+		d.id = append(d.id[:0], d.lastEventID...)
+		if len(d.name) == 0 {
+			d.name = append(d.name, defaultEventName...)
+		}
+	}
+
+	// Note the event source spec as defined by w3.org requires
+	// skips the event dispatching if the event name collides with
+	// the name of any event as defined in the DOM Events spec.
+	// Decoder does not perform this check, hence it could yield
+	// events that would not be valid in a browser.
+	return true
+}
+
+// splitField extracts the name and value of a field from a line, it returns
+// false for comments.
+func (d *Decoder) splitField(line []byte) (fieldName, value []byte, ok bool) {
+	colonIndex := bytes.IndexByte(line, ':')
+	switch {
+	case colonIndex == 0:
+		if len(d.recordHandlers) > 0 {
+			d.emit(Comment{Text: string(bytes.TrimPrefix(line[1:], []byte(" ")))})
+		}
+		return nil, nil, false
+	case colonIndex == -1:
+		return line, nil, true
+	case colonIndex < len(line)-1 && line[colonIndex+1] == ' ':
+		// Trim prefix space
+		return line[:colonIndex], line[colonIndex+2:], true
+	default:
+		return line[:colonIndex], line[colonIndex+1:], true
+	}
+}
+
+func (d *Decoder) parseField(fieldName, value []byte) error {
+	switch string(fieldName) {
+	case "event":
+		d.name = append(d.name[:0], value...)
+		d.eventSeen = true
+	case "data":
+		if d.maxEventSize > 0 && d.data.Len()+len(value) > d.maxEventSize {
+			return ErrEventTooLarge
+		}
+		d.data.Write(value)
+		d.data.WriteByte('\n')
+		d.eventSeen = true
+	case "id":
+		if bytes.IndexByte(value, 0) == -1 {
+			d.lastEventID = append(d.lastEventID[:0], value...)
+			d.id = append(d.id[:0], value...)
+			d.eventSeen = true
+			d.hasID = true
+		}
+	case "retry":
+		retry, err := strconv.Atoi(string(value))
+		if err == nil && retry >= 0 && d.retry != time.Duration(retry)*time.Millisecond {
+			d.retry = time.Duration(retry) * time.Millisecond
+			d.emitRetry(RetryChange{Retry: d.retry})
+		}
+	default:
+		// Ignore field
+		if len(d.recordHandlers) > 0 {
+			d.emit(Field{Name: string(fieldName), Value: string(value)})
+		}
+	}
+	return nil
 }
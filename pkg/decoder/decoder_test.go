@@ -1,12 +1,16 @@
 package decoder
 
 import (
+	"bufio"
 	"bytes"
 	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/alevinval/sse/internal/testutils"
+	"github.com/alevinval/sse/pkg/base"
 	"github.com/alevinval/sse/pkg/encoder"
 	"github.com/stretchr/testify/assert"
 )
@@ -198,6 +202,88 @@ func TestDecoder_Retry(t *testing.T) {
 	assert.Equal(t, 100*time.Millisecond, sut.Retry())
 }
 
+func TestDecoder_WhenLineTooLong_ThenReturnsScannerError(t *testing.T) {
+	sut := NewSize(strings.NewReader("data: "+strings.Repeat("a", 64)+"\n\n"), 16)
+
+	_, err := sut.Decode()
+	assert.ErrorIs(t, err, bufio.ErrTooLong)
+
+	_, err = sut.Decode()
+	assert.ErrorIs(t, err, bufio.ErrTooLong)
+}
+
+func TestDecoder_WhenReadFails_ThenReturnsReadError(t *testing.T) {
+	sut := New(io.MultiReader(strings.NewReader("data: partial\n"), iotest.ErrReader(io.ErrUnexpectedEOF)))
+
+	_, err := sut.Decode()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestDecoder_DecodeInto(t *testing.T) {
+	sut := newDecoder("id: 1\nevent: update\ndata: first\n\nid: 1\nevent: update\ndata: second\n\n")
+
+	actual := new(base.MessageEvent)
+	if assert.NoError(t, sut.DecodeInto(actual)) {
+		assert.Equal(t, &base.MessageEvent{ID: "1", Name: "update", Data: "first", HasID: true}, actual)
+	}
+	if assert.NoError(t, sut.DecodeInto(actual)) {
+		assert.Equal(t, &base.MessageEvent{ID: "1", Name: "update", Data: "second", HasID: true}, actual)
+	}
+	assert.ErrorIs(t, sut.DecodeInto(actual), io.EOF)
+}
+
+func TestDecoder_DecodeView(t *testing.T) {
+	sut := newDecoder("id: 1\nevent: update\ndata: first\ndata: line\n\n")
+
+	var actual EventView
+	if assert.NoError(t, sut.DecodeView(&actual)) {
+		assert.Equal(t, "1", string(actual.ID))
+		assert.Equal(t, "update", string(actual.Name))
+		assert.Equal(t, "first\nline", string(actual.Data))
+		assert.True(t, actual.HasID)
+	}
+	assert.ErrorIs(t, sut.DecodeView(&actual), io.EOF)
+}
+
+func TestDecoder_DecodeView_DoesNotAllocate(t *testing.T) {
+	sut := New(&repeatReader{data: getBenchmarkPayload(1000)})
+
+	var view EventView
+	allocs := testing.AllocsPerRun(100, func() {
+		sut.DecodeView(&view)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestDecoder_Reset(t *testing.T) {
+	sut := New(strings.NewReader("retry: 100\nid: 1\ndata: first\n\ndata: incomplete"), WithSpecMode())
+	sut.Decode()
+	_, err := sut.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+
+	sut.Reset(strings.NewReader("\uFEFFdata: second\n\n"))
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "second", actual.Data)
+		assert.Equal(t, "1", actual.ID)
+	}
+	assert.Equal(t, 100*time.Millisecond, sut.Retry())
+}
+
+func TestDecoder_Reset_DoesNotAllocate(t *testing.T) {
+	sut := newDecoder("")
+	reader := strings.NewReader("data: event\n\n")
+
+	var view EventView
+	allocs := testing.AllocsPerRun(100, func() {
+		reader.Seek(0, io.SeekStart)
+		sut.Reset(reader)
+		sut.DecodeView(&view)
+	})
+	assert.LessOrEqual(t, allocs, 1.0)
+}
+
 func BenchmarkDecodeEmptyEvent(b *testing.B) {
 	runDecodingBenchmark(b, []byte("data: \n\n"))
 }
@@ -226,11 +312,47 @@ func BenchmarkDecode16kEvent(b *testing.B) {
 	runDecodingBenchmark(b, getBenchmarkPayload(16000))
 }
 
+func BenchmarkDecodeIntoShortEvent(b *testing.B) {
+	runDecodingIntoBenchmark(b, []byte("data: short event\n\n"))
+}
+
+func BenchmarkDecodeInto1kEvent(b *testing.B) {
+	runDecodingIntoBenchmark(b, getBenchmarkPayload(1000))
+}
+
+func BenchmarkDecodeInto16kEvent(b *testing.B) {
+	runDecodingIntoBenchmark(b, getBenchmarkPayload(16000))
+}
+
+func BenchmarkDecodeViewShortEvent(b *testing.B) {
+	runDecodingViewBenchmark(b, []byte("data: short event\n\n"))
+}
+
+func BenchmarkDecodeView1kEvent(b *testing.B) {
+	runDecodingViewBenchmark(b, getBenchmarkPayload(1000))
+}
+
+func BenchmarkDecodeView16kEvent(b *testing.B) {
+	runDecodingViewBenchmark(b, getBenchmarkPayload(16000))
+}
+
 func newDecoder(data string) *Decoder {
 	reader := bytes.NewReader([]byte(data))
 	return New(reader)
 }
 
+// repeatReader reads the same data over and over again.
+type repeatReader struct {
+	data []byte
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data[r.off:])
+	r.off = (r.off + n) % len(r.data)
+	return n, nil
+}
+
 func runDecodingBenchmark(b *testing.B, data []byte) {
 	reader := bytes.NewReader(data)
 	decoder := New(reader)
@@ -242,6 +364,28 @@ func runDecodingBenchmark(b *testing.B, data []byte) {
 	}
 }
 
+func runDecodingIntoBenchmark(b *testing.B, data []byte) {
+	decoder := New(&repeatReader{data: data})
+	ev := new(base.MessageEvent)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		decoder.DecodeInto(ev)
+	}
+}
+
+func runDecodingViewBenchmark(b *testing.B, data []byte) {
+	decoder := New(&repeatReader{data: data})
+	var view EventView
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		decoder.DecodeView(&view)
+	}
+}
+
 func getBenchmarkPayload(dataSize int) []byte {
 	event := testutils.NewMessageEvent("event-id", "event-name", dataSize)
 	out := new(bytes.Buffer)
@@ -0,0 +1,49 @@
+package decoder
+
+// Option configures a Decoder, see New.
+type Option func(d *Decoder)
+
+// WithMaxEventSize limits the size of the data of an event, accumulated
+// across its data lines. Decoding a larger event fails with
+// ErrEventTooLarge. By default, the size is not limited.
+func WithMaxEventSize(size int) Option {
+	return func(d *Decoder) {
+		d.maxEventSize = size
+	}
+}
+
+// WithSpecMode decodes events as browsers do, following the HTML
+// specification. Events carry the last event ID buffer as ID, even when
+// they have no id field. Events without data lines are not dispatched and
+// unnamed events are named "message".
+func WithSpecMode() Option {
+	return func(d *Decoder) {
+		d.spec = true
+	}
+}
+
+// WithInvalidUTF8 sets what happens to lines that are not valid UTF-8, by
+// default InvalidUTF8Replace.
+func WithInvalidUTF8(handling InvalidUTF8Handling) Option {
+	return func(d *Decoder) {
+		d.invalidUTF8 = handling
+	}
+}
+
+// WithRecordHandler sets a function that is called with comments, unknown
+// fields and retry changes, which are otherwise discarded. It is called
+// while decoding, before the event the record belongs to is returned.
+func WithRecordHandler(handler func(r Record)) Option {
+	return func(d *Decoder) {
+		d.recordHandlers = append(d.recordHandlers, handler)
+	}
+}
+
+// WithRetryHandler sets a function that is called with retry changes only.
+// Unlike WithRecordHandler, comments and unknown fields are not converted
+// into records, so they do not allocate.
+func WithRetryHandler(handler func(change RetryChange)) Option {
+	return func(d *Decoder) {
+		d.retryHandlers = append(d.retryHandlers, handler)
+	}
+}
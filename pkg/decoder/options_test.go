@@ -0,0 +1,69 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_WithMaxEventSize(t *testing.T) {
+	sut := New(strings.NewReader("data: 1234\ndata: 5\n\n"), WithMaxEventSize(6))
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1234\n5", actual.Data)
+	}
+}
+
+func TestDecoder_WithMaxEventSize_WhenExceeded_ThenReturnsErrEventTooLarge(t *testing.T) {
+	sut := New(strings.NewReader("data: 1234\ndata: 56\n\ndata: 1\n\n"), WithMaxEventSize(6))
+
+	_, err := sut.Decode()
+	assert.ErrorIs(t, err, ErrEventTooLarge)
+
+	_, err = sut.Decode()
+	assert.ErrorIs(t, err, ErrEventTooLarge)
+}
+
+func TestDecoder_WithSpecMode_KeepsLastEventID(t *testing.T) {
+	sut := New(strings.NewReader("id: 1\ndata: first\n\ndata: second\n\nid\ndata: third\n\n"), WithSpecMode())
+	assert.True(t, sut.SpecMode())
+
+	for _, expected := range []struct{ id, data string }{{"1", "first"}, {"1", "second"}, {"", "third"}} {
+		actual, err := sut.Decode()
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected.id, actual.ID)
+			assert.Equal(t, expected.data, actual.Data)
+		}
+	}
+}
+
+func TestDecoder_WithSpecMode_SkipsEventsWithoutData(t *testing.T) {
+	sut := New(strings.NewReader("event: ping\nid: 7\n\ndata:\n\n"), WithSpecMode())
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "7", actual.ID)
+		assert.False(t, actual.HasID)
+		assert.Equal(t, "message", actual.Name)
+		assert.Equal(t, "", actual.Data)
+	}
+	assert.Equal(t, "7", sut.LastEventID())
+}
+
+func TestDecoder_WithoutSpecMode_DispatchesEventsWithoutData(t *testing.T) {
+	sut := New(strings.NewReader("event: ping\nid: 7\n\ndata: next\n\n"))
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ping", actual.Name)
+		assert.Equal(t, "7", actual.ID)
+	}
+
+	actual, err = sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", actual.ID)
+		assert.Equal(t, "", actual.Name)
+	}
+}
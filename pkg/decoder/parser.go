@@ -0,0 +1,126 @@
+package decoder
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+var _ (io.Writer) = (*Parser)(nil)
+
+// Maximum length of the lines buffered by a Parser, same as New.
+const maxLineSize = bufio.MaxScanTokenSize
+
+// Parser decodes events from a stream that is pushed to it in chunks, instead
+// of being read from an io.Reader. Lines and events can be split across
+// chunks at any byte, including between CR and LF.
+type Parser struct {
+	d       *Decoder
+	handler func(ev *base.MessageEvent)
+
+	// line holds the beginning of a line that continues in the next chunk
+	line []byte
+	// skipLF is set when the last chunk ended with CR
+	skipLF bool
+}
+
+// NewParser returns a Parser that calls the handler with every event. It
+// accepts the same options as New.
+func NewParser(handler func(ev *base.MessageEvent), opts ...Option) *Parser {
+	d := &Decoder{data: new(bytes.Buffer), retry: defaultRetry}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return &Parser{d: d, handler: handler}
+}
+
+// Retry returns the time to wait before attempting to reconnect to the
+// event source.
+func (p *Parser) Retry() time.Duration {
+	return p.d.Retry()
+}
+
+// LastEventID returns the value of the last id field parsed.
+func (p *Parser) LastEventID() string {
+	return p.d.LastEventID()
+}
+
+// Feed parses a chunk of the stream, calling the handler with every event
+// completed by it. Lines longer than bufio.MaxScanTokenSize fail with
+// bufio.ErrTooLong. Once an error is returned, the following calls return it
+// as well.
+func (p *Parser) Feed(chunk []byte) error {
+	if p.d.err != nil {
+		return p.d.err
+	}
+
+	for len(chunk) > 0 {
+		if p.skipLF {
+			p.skipLF = false
+			if chunk[0] == '\n' {
+				chunk = chunk[1:]
+				continue
+			}
+		}
+
+		i := bytes.IndexAny(chunk, "\r\n")
+		if i == -1 {
+			return p.buffer(chunk)
+		}
+
+		line := chunk[:i]
+		if len(p.line) > 0 {
+			if err := p.buffer(line); err != nil {
+				return err
+			}
+			line = p.line
+		} else if len(line) > maxLineSize {
+			p.d.err = bufio.ErrTooLong
+			return p.d.err
+		}
+		p.skipLF = chunk[i] == '\r'
+		chunk = chunk[i+1:]
+
+		if err := p.parseLine(line); err != nil {
+			return err
+		}
+		p.line = p.line[:0]
+	}
+	return nil
+}
+
+// Write feeds the chunk to the parser, see Feed.
+func (p *Parser) Write(chunk []byte) (int, error) {
+	if err := p.Feed(chunk); err != nil {
+		return 0, err
+	}
+	return len(chunk), nil
+}
+
+func (p *Parser) buffer(partial []byte) error {
+	if len(p.line)+len(partial) > maxLineSize {
+		p.d.err = bufio.ErrTooLong
+		return p.d.err
+	}
+	p.line = append(p.line, partial...)
+	return nil
+}
+
+func (p *Parser) parseLine(line []byte) error {
+	dispatch, err := p.d.parseLine(line)
+	if err != nil {
+		p.d.err = err
+		return err
+	}
+
+	if dispatch {
+		ev := new(base.MessageEvent)
+		p.d.fill(ev)
+		p.d.reset()
+		p.handler(ev)
+	}
+	return nil
+}
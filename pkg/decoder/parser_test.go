@@ -0,0 +1,108 @@
+package decoder
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+const parserStream = "\uFEFFretry: 100\r\n: comment\rid: 1\r\nevent: first\r\ndata: a\r\rdata: b\ndata:\r\n\r\nid\ndata: c\n\n"
+
+func TestParser_Feed(t *testing.T) {
+	sut, events := newParser()
+
+	assert.NoError(t, sut.Feed([]byte(parserStream)))
+
+	assert.Equal(t, []*base.MessageEvent{
+		{ID: "1", Name: "first", Data: "a", HasID: true},
+		{Data: "b\n"},
+		{Data: "c", HasID: true},
+	}, *events)
+	assert.Equal(t, 100, int(sut.Retry().Milliseconds()))
+}
+
+func TestParser_Feed_MatchesDecoderForAnySplit(t *testing.T) {
+	expected := decodeAll(parserStream)
+
+	for i := 0; i <= len(parserStream); i++ {
+		for j := i; j <= len(parserStream); j++ {
+			sut, events := newParser()
+			assert.NoError(t, sut.Feed([]byte(parserStream[:i])))
+			assert.NoError(t, sut.Feed([]byte(parserStream[i:j])))
+			assert.NoError(t, sut.Feed([]byte(parserStream[j:])))
+			assert.Equal(t, expected, *events, "split at %d and %d", i, j)
+		}
+	}
+}
+
+func TestParser_Feed_DispatchesWhenChunkEndsWithCR(t *testing.T) {
+	sut, events := newParser()
+
+	assert.NoError(t, sut.Feed([]byte("data: a\r\r")))
+	assert.Len(t, *events, 1)
+
+	assert.NoError(t, sut.Feed([]byte("\ndata: b\r\n\r\n")))
+	assert.Len(t, *events, 2)
+}
+
+func TestParser_Write(t *testing.T) {
+	sut, events := newParser()
+
+	w := bufio.NewWriterSize(sut, 16)
+	_, err := w.WriteString(strings.Repeat("data: some data\n\n", 10))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Flush())
+	assert.Len(t, *events, 10)
+}
+
+func TestParser_WhenLineTooLong_ThenReturnsError(t *testing.T) {
+	sut, _ := newParser()
+
+	chunk := []byte(strings.Repeat("a", maxLineSize/2))
+	assert.NoError(t, sut.Feed(chunk))
+	assert.NoError(t, sut.Feed(chunk))
+	assert.ErrorIs(t, sut.Feed(chunk), bufio.ErrTooLong)
+	assert.ErrorIs(t, sut.Feed([]byte("\n\n")), bufio.ErrTooLong)
+}
+
+func TestParser_WhenLineTooLongInSingleChunk_ThenReturnsError(t *testing.T) {
+	sut, events := newParser()
+
+	chunk := []byte("data: " + strings.Repeat("a", maxLineSize) + "\n\n")
+	assert.ErrorIs(t, sut.Feed(chunk), bufio.ErrTooLong)
+	assert.Empty(t, *events)
+}
+
+func TestParser_WithOptions(t *testing.T) {
+	var events []*base.MessageEvent
+	sut := NewParser(func(ev *base.MessageEvent) { events = append(events, ev) }, WithSpecMode(), WithMaxEventSize(4))
+
+	assert.NoError(t, sut.Feed([]byte("id: 1\n\ndata: a\n\n")))
+	assert.Equal(t, []*base.MessageEvent{{ID: "1", Name: "message", Data: "a"}}, events)
+	assert.Equal(t, "1", sut.LastEventID())
+
+	assert.ErrorIs(t, sut.Feed([]byte("data: too large\n\n")), ErrEventTooLarge)
+}
+
+func newParser() (*Parser, *[]*base.MessageEvent) {
+	events := new([]*base.MessageEvent)
+	sut := NewParser(func(ev *base.MessageEvent) {
+		*events = append(*events, ev)
+	})
+	return sut, events
+}
+
+func decodeAll(stream string) []*base.MessageEvent {
+	d := newDecoder(stream)
+	events := []*base.MessageEvent{}
+	for {
+		ev, err := d.Decode()
+		if err != nil {
+			return events
+		}
+		events = append(events, ev)
+	}
+}
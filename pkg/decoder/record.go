@@ -0,0 +1,52 @@
+package decoder
+
+import "time"
+
+var (
+	_ (Record) = Comment{}
+	_ (Record) = Field{}
+	_ (Record) = RetryChange{}
+)
+
+// Record is a line of the stream that does not belong to the data of an
+// event, it is either a Comment, a Field or a RetryChange. See
+// WithRecordHandler.
+type Record interface {
+	record()
+}
+
+// Comment is a line that starts with a colon, usually sent by servers as a
+// heartbeat.
+type Comment struct {
+	Text string
+}
+
+// Field is a field with an unknown name.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// RetryChange means a retry field changed the reconnection time.
+type RetryChange struct {
+	Retry time.Duration
+}
+
+func (Comment) record()     {}
+func (Field) record()       {}
+func (RetryChange) record() {}
+
+// emit calls the record handlers.
+func (d *Decoder) emit(r Record) {
+	for _, handler := range d.recordHandlers {
+		handler(r)
+	}
+}
+
+// emitRetry calls the retry handlers and the record handlers.
+func (d *Decoder) emitRetry(change RetryChange) {
+	for _, handler := range d.retryHandlers {
+		handler(change)
+	}
+	d.emit(change)
+}
@@ -0,0 +1,63 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_WithRecordHandler(t *testing.T) {
+	var records []Record
+	sut := New(
+		strings.NewReader(": heartbeat\n:raw\nretry: 100\nretry: 100\nx-vendor: value\nflag\ndata: a\n\nretry: 200\n"),
+		WithRecordHandler(func(r Record) { records = append(records, r) }),
+	)
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "a", actual.Data)
+	}
+	assert.Equal(t, []Record{
+		Comment{Text: "heartbeat"},
+		Comment{Text: "raw"},
+		RetryChange{Retry: 100 * time.Millisecond},
+		Field{Name: "x-vendor", Value: "value"},
+		Field{Name: "flag"},
+	}, records)
+
+	sut.Decode()
+	assert.Equal(t, RetryChange{Retry: 200 * time.Millisecond}, records[len(records)-1])
+}
+
+func TestDecoder_WithRetryHandler(t *testing.T) {
+	var changes []RetryChange
+	sut := New(
+		strings.NewReader(": heartbeat\nretry: 100\nretry: 100\nx-vendor: value\nretry: 200\ndata: a\n\n"),
+		WithRetryHandler(func(change RetryChange) { changes = append(changes, change) }),
+	)
+
+	sut.Decode()
+
+	assert.Equal(t, []RetryChange{{Retry: 100 * time.Millisecond}, {Retry: 200 * time.Millisecond}}, changes)
+}
+
+func TestDecoder_WithRetryHandler_ThenCommentsDoNotAllocate(t *testing.T) {
+	sut := New(strings.NewReader(""), WithRetryHandler(func(RetryChange) {}))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		sut.parseLine([]byte(": heartbeat"))
+		sut.parseLine([]byte("x-vendor: value"))
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestParser_WithRecordHandler(t *testing.T) {
+	var records []Record
+	sut := NewParser(func(ev *base.MessageEvent) {}, WithRecordHandler(func(r Record) { records = append(records, r) }))
+
+	assert.NoError(t, sut.Feed([]byte(": ping\n")))
+	assert.Equal(t, []Record{Comment{Text: "ping"}}, records)
+}
@@ -0,0 +1,184 @@
+package decoder
+
+import "io"
+
+// StreamEvent is an event whose data is read as a stream, see DecodeStream.
+type StreamEvent struct {
+	ID    string
+	Name  string
+	HasID bool
+
+	// Data reads the data lines of the event joined with LF. Fields that
+	// come after the first data line are only set once Data returns io.EOF.
+	Data io.Reader
+}
+
+// DecodeStream works like Decode, but the data of the event is not kept in
+// memory, it is read from the stream as StreamEvent.Data is read. Data must
+// be read before decoding the next event, otherwise the rest of it is
+// discarded. Data returns io.ErrUnexpectedEOF when the stream ends in the
+// middle of the event.
+func (d *Decoder) DecodeStream() (*StreamEvent, error) {
+	d.discardStream()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	d.reset()
+	for d.scanner.Scan() {
+		line, err := d.readLine(d.scanner.Bytes())
+		if err != nil {
+			d.err = err
+			return nil, err
+		}
+
+		if len(line) == 0 {
+			if d.complete(false) {
+				ev := &StreamEvent{Data: eofReader{}}
+				d.setStreamEvent(ev)
+				return ev, nil
+			}
+			continue
+		}
+
+		fieldName, value, ok := d.splitField(line)
+		if !ok {
+			continue
+		}
+		if string(fieldName) != "data" {
+			if err := d.parseField(fieldName, value); err != nil {
+				d.err = err
+				return nil, err
+			}
+			continue
+		}
+
+		if err := d.checkStreamSize(0, value); err != nil {
+			return nil, err
+		}
+		d.eventSeen = true
+		r := &dataReader{d: d, size: len(value)}
+		r.buf = append(r.buf, value...)
+		r.pending = r.buf
+		r.ev = &StreamEvent{Data: r}
+		d.setStreamEvent(r.ev)
+		d.stream = r
+		return r.ev, nil
+	}
+
+	d.err = d.scanner.Err()
+	if d.err == nil {
+		d.err = io.EOF
+	}
+	return nil, d.err
+}
+
+// setStreamEvent sets the fields of the event from the buffers of the
+// decoder, in spec mode they are set as if the event was complete.
+func (d *Decoder) setStreamEvent(ev *StreamEvent) {
+	ev.ID = string(d.id)
+	ev.Name = string(d.name)
+	ev.HasID = d.hasID
+	if d.spec {
+		ev.ID = string(d.lastEventID)
+		if ev.Name == "" {
+			ev.Name = defaultEventName
+		}
+	}
+}
+
+// discardStream reads the rest of the data of the current stream event.
+func (d *Decoder) discardStream() {
+	if d.stream != nil {
+		io.Copy(io.Discard, d.stream)
+		d.stream = nil
+	}
+}
+
+func (d *Decoder) checkStreamSize(size int, value []byte) error {
+	if d.maxEventSize > 0 && size+len(value) > d.maxEventSize {
+		d.err = ErrEventTooLarge
+		return d.err
+	}
+	return nil
+}
+
+// dataReader reads the data lines of an event from the decoder.
+type dataReader struct {
+	d       *Decoder
+	ev      *StreamEvent
+	buf     []byte
+	pending []byte
+	size    int
+	err     error
+}
+
+func (r *dataReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.next()
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next reads lines until the next data line or the end of the event.
+func (r *dataReader) next() {
+	d := r.d
+	for d.scanner.Scan() {
+		line, err := d.readLine(d.scanner.Bytes())
+		if err != nil {
+			d.err, r.err = err, err
+			return
+		}
+
+		if len(line) == 0 {
+			d.complete(true)
+			d.setStreamEvent(r.ev)
+			r.err = io.EOF
+			return
+		}
+
+		fieldName, value, ok := d.splitField(line)
+		if !ok {
+			continue
+		}
+		if string(fieldName) != "data" {
+			if err := d.parseField(fieldName, value); err != nil {
+				d.err, r.err = err, err
+				return
+			}
+			continue
+		}
+
+		// Lines are joined with LF
+		if err := d.checkStreamSize(r.size+1, value); err != nil {
+			r.err = err
+			return
+		}
+		r.size += 1 + len(value)
+		r.buf = append(append(r.buf[:0], '\n'), value...)
+		r.pending = r.buf
+		return
+	}
+
+	d.err = d.scanner.Err()
+	if d.err == nil {
+		d.err = io.EOF
+	}
+	r.err = d.err
+	if r.err == io.EOF {
+		r.err = io.ErrUnexpectedEOF
+	}
+}
+
+// eofReader is the data of events without data lines.
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
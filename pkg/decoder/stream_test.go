@@ -0,0 +1,104 @@
+package decoder
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_DecodeStream(t *testing.T) {
+	sut := newDecoder("id: 1\nevent: snapshot\ndata: {\"a\":\ndata:\ndata: 1}\n\n")
+
+	actual, err := sut.DecodeStream()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1", actual.ID)
+		assert.Equal(t, "snapshot", actual.Name)
+		assert.True(t, actual.HasID)
+
+		data, err := io.ReadAll(actual.Data)
+		assert.NoError(t, err)
+		assert.Equal(t, "{\"a\":\n\n1}", string(data))
+	}
+
+	_, err = sut.DecodeStream()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoder_DecodeStream_IntoJSONDecoder(t *testing.T) {
+	sut := newDecoder("data: {\"values\":\ndata: [1, 2, 3]}\n\ndata: {}\n\n")
+
+	actual, err := sut.DecodeStream()
+	if assert.NoError(t, err) {
+		var v struct{ Values []int }
+		assert.NoError(t, json.NewDecoder(actual.Data).Decode(&v))
+		assert.Equal(t, []int{1, 2, 3}, v.Values)
+	}
+
+	actual, err = sut.DecodeStream()
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(actual.Data)
+		assert.Equal(t, "{}", string(data))
+	}
+}
+
+func TestDecoder_DecodeStream_SetsFieldsAfterDataOnceRead(t *testing.T) {
+	sut := newDecoder("data: a\nid: 2\nevent: late\n\n")
+
+	actual, err := sut.DecodeStream()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", actual.ID)
+		io.ReadAll(actual.Data)
+		assert.Equal(t, "2", actual.ID)
+		assert.Equal(t, "late", actual.Name)
+	}
+}
+
+func TestDecoder_DecodeStream_DiscardsUnreadData(t *testing.T) {
+	sut := newDecoder("data: first\ndata: more\n\ndata: second\n\n")
+
+	_, err := sut.DecodeStream()
+	assert.NoError(t, err)
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "second", actual.Data)
+	}
+}
+
+func TestDecoder_DecodeStream_WithoutData(t *testing.T) {
+	sut := newDecoder("event: ping\n\n")
+
+	actual, err := sut.DecodeStream()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ping", actual.Name)
+		data, err := io.ReadAll(actual.Data)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+	}
+}
+
+func TestDecoder_DecodeStream_WhenStreamEnds_ThenReturnsUnexpectedEOF(t *testing.T) {
+	sut := newDecoder("data: incomplete\ndata: event")
+
+	actual, err := sut.DecodeStream()
+	if assert.NoError(t, err) {
+		_, err = io.ReadAll(actual.Data)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	}
+
+	_, err = sut.DecodeStream()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoder_DecodeStream_WithMaxEventSize(t *testing.T) {
+	sut := New(strings.NewReader("data: 12\ndata: 345\n\n"), WithMaxEventSize(5))
+
+	actual, err := sut.DecodeStream()
+	if assert.NoError(t, err) {
+		_, err = io.ReadAll(actual.Data)
+		assert.ErrorIs(t, err, ErrEventTooLarge)
+	}
+}
@@ -0,0 +1,57 @@
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf8"
+)
+
+//go:generate stringer -type=InvalidUTF8Handling
+
+// InvalidUTF8Handling defines what happens to lines of the stream that are
+// not valid UTF-8, see WithInvalidUTF8.
+type InvalidUTF8Handling uint8
+
+const (
+	// InvalidUTF8Replace replaces every invalid byte with U+FFFD, as
+	// required by the spec.
+	InvalidUTF8Replace InvalidUTF8Handling = iota
+	// InvalidUTF8Reject fails decoding with ErrInvalidUTF8.
+	InvalidUTF8Reject
+	// InvalidUTF8PassThrough keeps the bytes as they are.
+	InvalidUTF8PassThrough
+)
+
+// ErrInvalidUTF8 means the stream is not valid UTF-8, see InvalidUTF8Reject.
+var ErrInvalidUTF8 = errors.New("decoder: invalid UTF-8 in the stream")
+
+// Byte order mark, which is stripped from the beginning of the stream.
+var bom = []byte("\uFEFF")
+
+// readLine returns the line without the byte order mark and with invalid
+// UTF-8 handled.
+func (d *Decoder) readLine(line []byte) ([]byte, error) {
+	if !d.started {
+		d.started = true
+		line = bytes.TrimPrefix(line, bom)
+	}
+
+	if d.invalidUTF8 == InvalidUTF8PassThrough || utf8.Valid(line) {
+		return line, nil
+	}
+	if d.invalidUTF8 == InvalidUTF8Reject {
+		return nil, ErrInvalidUTF8
+	}
+
+	d.line = d.line[:0]
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		if r == utf8.RuneError && size == 1 {
+			d.line = utf8.AppendRune(d.line, utf8.RuneError)
+		} else {
+			d.line = append(d.line, line[:size]...)
+		}
+		line = line[size:]
+	}
+	return d.line, nil
+}
@@ -0,0 +1,54 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder_StripsByteOrderMark(t *testing.T) {
+	sut := newDecoder("\uFEFFdata: first\n\n\uFEFFdata: second\n\ndata: third\n\n")
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "first", actual.Data)
+	}
+
+	// Only the byte order mark at the beginning of the stream is stripped
+	actual, err = sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "third", actual.Data)
+	}
+}
+
+func TestDecoder_ReplacesInvalidUTF8(t *testing.T) {
+	sut := newDecoder("event: n\xffme\ndata: \xe2\x82\n\n")
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "n\uFFFDme", actual.Name)
+		assert.Equal(t, "\uFFFD\uFFFD", actual.Data)
+	}
+}
+
+func TestDecoder_WithInvalidUTF8Reject(t *testing.T) {
+	sut := New(strings.NewReader("data: valid\n\ndata: \xff\n\n"), WithInvalidUTF8(InvalidUTF8Reject))
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "valid", actual.Data)
+	}
+
+	_, err = sut.Decode()
+	assert.ErrorIs(t, err, ErrInvalidUTF8)
+}
+
+func TestDecoder_WithInvalidUTF8PassThrough(t *testing.T) {
+	sut := New(strings.NewReader("data: \xff\n\n"), WithInvalidUTF8(InvalidUTF8PassThrough))
+
+	actual, err := sut.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "\xff", actual.Data)
+	}
+}
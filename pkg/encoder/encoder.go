@@ -1,13 +1,11 @@
 package encoder
 
 import (
-	"bufio"
 	"bytes"
 	"io"
 	"strconv"
 	"strings"
 
-	"github.com/alevinval/sse/internal"
 	"github.com/alevinval/sse/pkg/base"
 )
 
@@ -43,18 +41,20 @@ func (e *Encoder) WriteEvent(event base.MessageEventGetter) (int, error) {
 		e.buf.WriteByte('\n')
 	}
 
-	if event.GetData() != "" {
-		input := strings.NewReader(event.GetData())
-		scanner := bufio.NewScanner(input)
-		scanner.Split(internal.ScanLinesCR)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if len(line) == 0 {
+	// Every line is encoded, including empty and trailing ones, as the
+	// decoder joins data lines with LF. CR and CRLF become LF.
+	if data := event.GetData(); data != "" {
+		for {
+			i := strings.IndexAny(data, "\r\n")
+			if i == -1 {
+				e.writeData(data)
 				break
 			}
-			e.buf.WriteString("data: ")
-			e.buf.WriteString(line)
-			e.buf.WriteByte('\n')
+			e.writeData(data[:i])
+			if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+				i++
+			}
+			data = data[i+1:]
 		}
 	}
 
@@ -63,6 +63,16 @@ func (e *Encoder) WriteEvent(event base.MessageEventGetter) (int, error) {
 	return e.out.Write(e.buf.Bytes())
 }
 
+func (e *Encoder) writeData(line string) {
+	if line == "" {
+		e.buf.WriteString("data\n")
+		return
+	}
+	e.buf.WriteString("data: ")
+	e.buf.WriteString(line)
+	e.buf.WriteByte('\n')
+}
+
 // WriteRetry encodes the retry field.
 func (e *Encoder) WriteRetry(retryDelayInMillis int) {
 	e.buf.Reset()
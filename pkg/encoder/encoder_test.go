@@ -64,6 +64,22 @@ func TestEncoder_WriteEvent_EncodesMultiLineInput(t *testing.T) {
 	assert.Equal(t, "id: abc\nevent: test\ndata: line1\ndata: line2\n\n", out.String())
 }
 
+func TestEncoder_WriteEvent_EncodesEmptyLines(t *testing.T) {
+	sut, out := getEncoder()
+
+	sut.WriteEvent(&base.MessageEvent{Data: "a\n\nb\n"})
+
+	assert.Equal(t, "data: a\ndata\ndata: b\ndata\n\n", out.String())
+}
+
+func TestEncoder_WriteEvent_EncodesCarriageReturns(t *testing.T) {
+	sut, out := getEncoder()
+
+	sut.WriteEvent(&base.MessageEvent{Data: "a\r\nb\rc\r"})
+
+	assert.Equal(t, "data: a\ndata: b\ndata: c\ndata\n\n", out.String())
+}
+
 func TestEncoder_WriteEvent_EncodesFullEvent(t *testing.T) {
 	event := &base.MessageEvent{ID: "event-id", Name: "event-name", Data: "event-data"}
 	sut, out := getEncoder()
@@ -0,0 +1,68 @@
+package encoder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/decoder"
+	"github.com/stretchr/testify/assert"
+)
+
+// IDs and names cannot contain line terminators, and IDs with NULL are
+// ignored by decoders.
+var fieldReplacer = strings.NewReplacer("\r", "", "\n", "", "\x00", "")
+
+// Data lines are joined with LF when decoded.
+var dataReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func TestEncoder_RoundTrip(t *testing.T) {
+	roundTrip := func(id, name, data string, hasID bool) bool {
+		event := &base.MessageEvent{
+			ID:    fieldReplacer.Replace(id),
+			Name:  fieldReplacer.Replace(name),
+			Data:  data,
+			HasID: hasID,
+		}
+		if event.ID == "" && !event.HasID && event.Name == "" && event.Data == "" {
+			// Nothing is encoded
+			return true
+		}
+
+		out := new(bytes.Buffer)
+		New(out).WriteEvent(event)
+		actual, err := decoder.New(out).Decode()
+
+		return assert.NoError(t, err) &&
+			assert.Equal(t, &base.MessageEvent{
+				ID:    event.ID,
+				Name:  event.Name,
+				Data:  dataReplacer.Replace(event.Data),
+				HasID: event.HasID || event.ID != "",
+			}, actual)
+	}
+
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 1000}))
+}
+
+func TestEncoder_RoundTrip_MultiLineData(t *testing.T) {
+	roundTrip := func(lines []string, separators []uint8) bool {
+		var data strings.Builder
+		for i, line := range lines {
+			data.WriteString(fieldReplacer.Replace(line))
+			if i < len(separators) {
+				data.WriteString([]string{"\n", "\r", "\r\n", "\n\n"}[separators[i]%4])
+			}
+		}
+
+		out := new(bytes.Buffer)
+		New(out).WriteEvent(&base.MessageEvent{Name: "lines", Data: data.String()})
+		actual, err := decoder.New(out).Decode()
+
+		return assert.NoError(t, err) && assert.Equal(t, dataReplacer.Replace(data.String()), actual.Data)
+	}
+
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 1000}))
+}
@@ -0,0 +1,95 @@
+package eventsource
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+var (
+	_ (BackoffPolicy) = (*ConstantBackoff)(nil)
+	_ (BackoffPolicy) = (*ExponentialBackoff)(nil)
+	_ (BackoffPolicy) = (*DecorrelatedJitterBackoff)(nil)
+)
+
+// BackoffPolicy decides how long the EventSource waits before attempting to
+// reconnect.
+type BackoffPolicy interface {
+	// Delay returns the time to wait before a reconnection attempt. Attempts
+	// are counted from 1 and start over once a connection is established.
+	// retry is the reconnection time advertised by the server and last is the
+	// delay returned for the previous attempt, zero for the first one.
+	Delay(attempt int, retry, last time.Duration) time.Duration
+}
+
+// ConstantBackoff always waits the reconnection time advertised by the
+// server. This is the default policy, as described by the spec.
+type ConstantBackoff struct{}
+
+// Delay returns the retry time.
+func (ConstantBackoff) Delay(_ int, retry, _ time.Duration) time.Duration {
+	return retry
+}
+
+// ExponentialBackoff multiplies the reconnection time advertised by the
+// server on every consecutive attempt.
+type ExponentialBackoff struct {
+	// Multiplier applied on every attempt, defaults to 2.
+	Multiplier float64
+	// Max delay, zero means there is no maximum.
+	Max time.Duration
+	// Jitter is the fraction of the delay that is randomized, from 0 (no
+	// jitter) to 1 (the delay is anywhere between zero and its full value).
+	Jitter float64
+}
+
+// Delay returns retry * Multiplier^(attempt-1), capped and randomized.
+func (b ExponentialBackoff) Delay(attempt int, retry, _ time.Duration) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(retry) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	jitter := math.Min(math.Max(b.Jitter, 0), 1)
+	delay -= jitter * delay * rand.Float64()
+	return time.Duration(delay)
+}
+
+// DecorrelatedJitterBackoff picks a random delay between the reconnection time
+// advertised by the server and three times the previous delay. It spreads
+// out reconnections of many clients better than ExponentialBackoff.
+type DecorrelatedJitterBackoff struct {
+	// Max delay, zero means there is no maximum.
+	Max time.Duration
+}
+
+// Delay returns a random delay in [retry, 3*last), capped to Max.
+func (b DecorrelatedJitterBackoff) Delay(_ int, retry, last time.Duration) time.Duration {
+	upper := 3 * last
+	if upper < retry {
+		upper = retry
+	}
+
+	delay := retry + time.Duration(float64(upper-retry)*rand.Float64())
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// clampRetry bounds the reconnection time advertised by the server, zero
+// bounds are ignored.
+func clampRetry(retry, min, max time.Duration) time.Duration {
+	if min > 0 && retry < min {
+		retry = min
+	}
+	if max > 0 && retry > max {
+		retry = max
+	}
+	return retry
+}
@@ -0,0 +1,94 @@
+package eventsource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff_ReturnsRetry(t *testing.T) {
+	sut := ConstantBackoff{}
+
+	assert.Equal(t, time.Second, sut.Delay(1, time.Second, 0))
+	assert.Equal(t, time.Second, sut.Delay(5, time.Second, time.Second))
+}
+
+func TestExponentialBackoff_GrowsUntilMax(t *testing.T) {
+	sut := ExponentialBackoff{Max: 5 * time.Second}
+
+	assert.Equal(t, 1*time.Second, sut.Delay(1, time.Second, 0))
+	assert.Equal(t, 2*time.Second, sut.Delay(2, time.Second, 0))
+	assert.Equal(t, 4*time.Second, sut.Delay(3, time.Second, 0))
+	assert.Equal(t, 5*time.Second, sut.Delay(4, time.Second, 0))
+}
+
+func TestExponentialBackoff_CustomMultiplier(t *testing.T) {
+	sut := ExponentialBackoff{Multiplier: 3}
+
+	assert.Equal(t, 9*time.Second, sut.Delay(3, time.Second, 0))
+}
+
+func TestExponentialBackoff_JitterWithinBounds(t *testing.T) {
+	sut := ExponentialBackoff{Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := sut.Delay(2, time.Second, 0)
+		assert.GreaterOrEqual(t, delay, 1*time.Second)
+		assert.LessOrEqual(t, delay, 2*time.Second)
+	}
+}
+
+func TestDecorrelatedJitterBackoff_WithinBounds(t *testing.T) {
+	sut := DecorrelatedJitterBackoff{Max: 10 * time.Second}
+
+	var delay time.Duration
+	for i := 1; i < 100; i++ {
+		last := delay
+		delay = sut.Delay(i, time.Second, last)
+		assert.GreaterOrEqual(t, delay, time.Second)
+		assert.LessOrEqual(t, delay, 10*time.Second)
+		if 3*last > time.Second && 3*last < 10*time.Second {
+			assert.Less(t, delay, 3*last)
+		}
+	}
+}
+
+func TestClampRetry(t *testing.T) {
+	for _, test := range []struct {
+		retry, min, max, expected time.Duration
+	}{
+		{time.Second, 0, 0, time.Second},
+		{time.Second, 2 * time.Second, 0, 2 * time.Second},
+		{time.Second, 0, time.Millisecond, time.Millisecond},
+		{time.Second, time.Millisecond, time.Minute, time.Second},
+	} {
+		assert.Equal(t, test.expected, clampRetry(test.retry, test.min, test.max))
+	}
+}
+
+type recordingBackoff struct {
+	retries []time.Duration
+}
+
+func (rb *recordingBackoff) Delay(attempt int, retry, last time.Duration) time.Duration {
+	rb.retries = append(rb.retries, retry)
+	return time.Millisecond
+}
+
+func TestEventSource_WithBackoffAndRetryBounds(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		backoff := &recordingBackoff{}
+		sut, _ := New(handler.URL, WithBackoff(backoff), WithRetryBounds(0, 10*time.Millisecond))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(5000, sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		assertConnectionWithinDeadline(t, handler, 0, 100*time.Millisecond)
+
+		assert.Equal(t, []time.Duration{10 * time.Millisecond}, backoff.retries)
+	})
+}
@@ -0,0 +1,148 @@
+package eventsource
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+var (
+	_ (CheckpointStore) = (*MemoryCheckpointStore)(nil)
+	_ (CheckpointStore) = (*FileCheckpointStore)(nil)
+)
+
+// CheckpointStore persists the last event ID, so the EventSource can resume
+// the stream where it was left, see WithCheckpointStore.
+// Implementations must be safe for concurrent use.
+type CheckpointStore interface {
+	// Load returns the last saved event ID, empty when nothing was saved.
+	Load() (string, error)
+	// Save the last event ID.
+	Save(id string) error
+}
+
+// MemoryCheckpointStore keeps the last event ID in memory.
+type MemoryCheckpointStore struct {
+	mu sync.RWMutex
+	id string
+}
+
+// Load returns the last saved event ID.
+func (s *MemoryCheckpointStore) Load() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.id, nil
+}
+
+// Save the last event ID.
+func (s *MemoryCheckpointStore) Save(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id = id
+	return nil
+}
+
+// FileCheckpointStore keeps the last event ID in a file, which is replaced
+// atomically on every save.
+type FileCheckpointStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileCheckpointStore returns a store that keeps the last event ID in the
+// file at path.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load returns the contents of the file, empty if it does not exist.
+func (s *FileCheckpointStore) Load() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// Save writes the event ID to a temporary file that then replaces the
+// checkpoint file, so a crash never leaves a partially written checkpoint.
+func (s *FileCheckpointStore) Save(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.WriteString(id); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+type checkpoint struct {
+	store     CheckpointStore
+	manualAck bool
+	saved     string
+}
+
+// Ack saves the ID of an event that has been handled by the application
+// into the checkpoint store, see WithManualAck. Events without ID are
+// ignored.
+func (es *EventSource) Ack(ev *base.MessageEvent) error {
+	if es.checkpoint.store == nil {
+		return nil
+	}
+	if id, hasID := ev.GetID(); id != "" || hasID {
+		return es.checkpoint.store.Save(id)
+	}
+	return nil
+}
+
+// loadCheckpoint restores the last event ID from the checkpoint store.
+func (es *EventSource) loadCheckpoint() error {
+	if es.checkpoint.store == nil {
+		return nil
+	}
+
+	id, err := es.checkpoint.store.Load()
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		es.setLastEventID(id)
+	}
+	es.checkpoint.saved = es.getLastEventID()
+	return nil
+}
+
+// commitCheckpoint saves the last event ID, unless it is acknowledged by the
+// application.
+func (es *EventSource) commitCheckpoint() {
+	lastEventID := es.getLastEventID()
+	if es.checkpoint.store == nil || es.checkpoint.manualAck || es.checkpoint.saved == lastEventID {
+		return
+	}
+
+	if err := es.checkpoint.store.Save(lastEventID); err != nil {
+		es.logger.Warn("eventsource: cannot save checkpoint", slog.Any("err", err))
+		return
+	}
+	es.checkpoint.saved = lastEventID
+}
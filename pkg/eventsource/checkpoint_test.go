@@ -0,0 +1,155 @@
+package eventsource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alevinval/sse/internal/testutils"
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/decoder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCheckpointStore(t *testing.T) {
+	sut := &MemoryCheckpointStore{}
+
+	assert.NoError(t, sut.Save("event-id"))
+
+	id, err := sut.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "event-id", id)
+}
+
+func TestFileCheckpointStore_WhenMissing_ThenLoadsEmpty(t *testing.T) {
+	sut := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint"))
+
+	id, err := sut.Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+}
+
+func TestFileCheckpointStore_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	sut := NewFileCheckpointStore(filepath.Join(dir, "checkpoint"))
+
+	assert.NoError(t, sut.Save("first"))
+	assert.NoError(t, sut.Save("second"))
+
+	id, err := NewFileCheckpointStore(filepath.Join(dir, "checkpoint")).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "second", id)
+
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "temporary files must be removed")
+}
+
+func TestFileCheckpointStore_WhenDirectoryMissing_ThenSaveFails(t *testing.T) {
+	sut := NewFileCheckpointStore(filepath.Join(t.TempDir(), "missing", "checkpoint"))
+
+	assert.Error(t, sut.Save("event-id"))
+}
+
+func TestEventSource_WithLastEventID(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.ExpectLastEventID("initial-id")
+
+		sut, err := New(handler.URL, WithLastEventID("initial-id"))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+	})
+}
+
+func TestEventSource_WithSpecMode_ThenReconnectsWithIDOfEventsWithoutData(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		sut, err := New(handler.URL, WithDecoderOptions(decoder.WithSpecMode()))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.WriteEvent(&base.MessageEvent{ID: "7"})
+		handler.WriteEvent(&base.MessageEvent{Data: "data"})
+		assertReceive(t, sut, &base.MessageEvent{ID: "7", Name: "message", Data: "data"})
+
+		handler.ExpectLastEventID("7")
+		handler.CloseActiveRequest(true)
+		<-handler.Connected
+	})
+}
+
+func TestEventSource_WithCheckpointStore_ThenCheckpointTakesPrecedence(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.ExpectLastEventID("stored-id")
+		store := &MemoryCheckpointStore{}
+		store.Save("stored-id")
+
+		sut, err := New(handler.URL, WithLastEventID("initial-id"), WithCheckpointStore(store))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+	})
+}
+
+func TestEventSource_WithCheckpointStore_ThenSavesDeliveredEvents(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		store := &MemoryCheckpointStore{}
+		sut, _ := New(handler.URL, WithCheckpointStore(store))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{ID: "event-id"})
+		assertReceive(t, sut, &base.MessageEvent{ID: "event-id"})
+
+		testutils.ExpectCondition(t, func() bool {
+			id, _ := store.Load()
+			return id == "event-id"
+		})
+	})
+}
+
+func TestEventSource_WithManualAck(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		store := &MemoryCheckpointStore{}
+		sut, _ := New(handler.URL, WithCheckpointStore(store), WithManualAck())
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{ID: "first"})
+		handler.WriteEvent(&base.MessageEvent{ID: "second"})
+		first := <-sut.MessageEvents()
+		assertReceive(t, sut, &base.MessageEvent{ID: "second"})
+
+		id, _ := store.Load()
+		assert.Equal(t, "", id)
+
+		assert.NoError(t, sut.Ack(first))
+		id, _ = store.Load()
+		assert.Equal(t, "first", id)
+	})
+}
+
+type failingCheckpointStore struct {
+	MemoryCheckpointStore
+}
+
+func (*failingCheckpointStore) Load() (string, error) {
+	return "", errors.New("cannot load")
+}
+
+func TestEventSource_WhenCheckpointCannotLoad_ThenReturnsError(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, err := New(handler.URL, WithCheckpointStore(&failingCheckpointStore{}), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		assert.EqualError(t, err, "cannot load")
+		assertStates(t, []ReadyState{Closed}, sut)
+	})
+}
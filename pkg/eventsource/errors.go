@@ -0,0 +1,140 @@
+package eventsource
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"syscall"
+
+	"github.com/alevinval/sse/pkg/decoder"
+)
+
+var (
+	_ (error) = (*ErrorKind)(nil)
+	_ (error) = (*Error)(nil)
+)
+
+var (
+	// ErrConnectionRefused means the server refused the connection.
+	ErrConnectionRefused = temporaryKind("eventsource: connection refused")
+
+	// ErrTLS means the TLS handshake with the server failed, usually
+	// because its certificate cannot be verified.
+	ErrTLS = fatalKind("eventsource: TLS handshake failed")
+
+	// ErrStreamEnded means the server ended the stream cleanly.
+	ErrStreamEnded = temporaryKind("eventsource: stream ended by the server")
+
+	// ErrLineTooLong means the stream contains a line that does not fit in
+	// the buffer of the decoder.
+	ErrLineTooLong = fatalKind("eventsource: line too long")
+
+	// ErrEventTooLarge means the stream contains an event larger than
+	// allowed, see decoder.WithMaxEventSize.
+	ErrEventTooLarge = fatalKind("eventsource: event too large")
+
+	// ErrInvalidUTF8 means the stream is not valid UTF-8, see
+	// decoder.InvalidUTF8Reject.
+	ErrInvalidUTF8 = fatalKind("eventsource: invalid UTF-8 in the stream")
+
+	// ErrClosed means the EventSource was closed with Close.
+	ErrClosed = &ErrorKind{msg: "eventsource: closed"}
+)
+
+// ErrorKind classifies the failures of an EventSource, it is either matched
+// directly or with errors.Is on an Error.
+type ErrorKind struct {
+	msg       string
+	temporary bool
+	fatal     bool
+}
+
+func temporaryKind(msg string) *ErrorKind {
+	return &ErrorKind{msg: msg, temporary: true}
+}
+
+func fatalKind(msg string) *ErrorKind {
+	return &ErrorKind{msg: msg, fatal: true}
+}
+
+func (k *ErrorKind) Error() string {
+	return k.msg
+}
+
+// Temporary reports whether the EventSource reconnects after the failure.
+func (k *ErrorKind) Temporary() bool {
+	return k.temporary
+}
+
+// Fatal reports whether the failure closes the EventSource.
+func (k *ErrorKind) Fatal() bool {
+	return k.fatal
+}
+
+// Error is a failure of a known kind caused by another error, which is
+// matched with errors.Is and errors.As as well.
+type Error struct {
+	Kind *ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Kind.msg + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Temporary reports whether the EventSource reconnects after the failure.
+func (e *Error) Temporary() bool {
+	return e.Kind.Temporary()
+}
+
+// Fatal reports whether the failure closes the EventSource.
+func (e *Error) Fatal() bool {
+	return e.Kind.Fatal()
+}
+
+// classifyConnectError wraps the errors of HTTP requests of a known kind.
+func classifyConnectError(err error) error {
+	var (
+		certErr      *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &Error{Kind: ErrConnectionRefused, Err: err}
+	case errors.As(err, &certErr),
+		errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return &Error{Kind: ErrTLS, Err: err}
+	default:
+		return err
+	}
+}
+
+// classifyDecodeError wraps the errors of reading the stream of a known kind.
+func classifyDecodeError(err error) error {
+	switch {
+	case err == io.EOF:
+		return &Error{Kind: ErrStreamEnded, Err: err}
+	case errors.Is(err, bufio.ErrTooLong):
+		return &Error{Kind: ErrLineTooLong, Err: err}
+	case errors.Is(err, decoder.ErrEventTooLarge):
+		return &Error{Kind: ErrEventTooLarge, Err: err}
+	case errors.Is(err, decoder.ErrInvalidUTF8):
+		return &Error{Kind: ErrInvalidUTF8, Err: err}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,147 @@
+package eventsource
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/decoder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError_MatchesKindAndCause(t *testing.T) {
+	cause := errors.New("cause")
+	err := error(&Error{Kind: ErrConnectionRefused, Err: cause})
+
+	assert.Equal(t, "eventsource: connection refused: cause", err.Error())
+	assert.ErrorIs(t, err, ErrConnectionRefused)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrTLS)
+}
+
+func TestErrorKind_Classification(t *testing.T) {
+	for _, kind := range []*ErrorKind{ErrConnectionRefused, ErrStreamEnded, ErrIdleTimeout} {
+		assert.True(t, kind.Temporary(), kind.Error())
+		assert.False(t, kind.Fatal(), kind.Error())
+	}
+	for _, kind := range []*ErrorKind{ErrTLS, ErrContentType, ErrLineTooLong, ErrEventTooLarge, ErrInvalidUTF8, ErrSlowConsumer, ErrTooManyRedirects} {
+		assert.False(t, kind.Temporary(), kind.Error())
+		assert.True(t, kind.Fatal(), kind.Error())
+	}
+	assert.False(t, ErrClosed.Temporary())
+	assert.False(t, ErrClosed.Fatal())
+}
+
+func TestClassifyDecodeError(t *testing.T) {
+	assert.ErrorIs(t, classifyDecodeError(io.EOF), ErrStreamEnded)
+	assert.ErrorIs(t, classifyDecodeError(io.EOF), io.EOF)
+	assert.ErrorIs(t, classifyDecodeError(bufio.ErrTooLong), ErrLineTooLong)
+	assert.ErrorIs(t, classifyDecodeError(decoder.ErrEventTooLarge), ErrEventTooLarge)
+	assert.ErrorIs(t, classifyDecodeError(decoder.ErrInvalidUTF8), ErrInvalidUTF8)
+	assert.Equal(t, io.ErrUnexpectedEOF, classifyDecodeError(io.ErrUnexpectedEOF))
+}
+
+func TestEventSource_WhenConnectionRefused_ThenReturnsErrConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	url := "http://" + listener.Addr().String()
+	listener.Close()
+
+	sut, err := New(url)
+	defer sut.Close()
+
+	var classified *Error
+	if assert.ErrorAs(t, err, &classified) {
+		assert.Equal(t, ErrConnectionRefused, classified.Kind)
+		assert.True(t, classified.Temporary())
+	}
+}
+
+func TestEventSource_WhenTLSFails_ThenReturnsErrTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	sut, err := New(server.URL)
+	defer sut.Close()
+
+	assert.ErrorIs(t, err, ErrTLS)
+}
+
+func TestEventSource_WhenStreamEnds_ThenReportsErrStreamEnded(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		sut, _ := New(handler.URL, WithRetryBounds(0, time.Millisecond), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.CloseActiveRequest(true)
+		<-handler.Connected
+
+		<-sut.ReadyState()
+		<-sut.ReadyState()
+		status := <-sut.ReadyState()
+		assert.Equal(t, Connecting, status.ReadyState)
+		assert.ErrorIs(t, status.Err, ErrStreamEnded)
+	})
+}
+
+func TestEventSource_WhenClosed_ThenReportsErrClosed(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL)
+
+		<-handler.Connected
+		sut.Close()
+
+		assert.Equal(t, ErrClosed, sut.CurrentState().Err)
+	})
+}
+
+func TestEventSource_WhenEventTooLarge_ThenCloses(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithDecoderOptions(decoder.WithMaxEventSize(4)), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Data: "too large"})
+
+		assertNoReceives(t, sut)
+		assert.ErrorIs(t, sut.CurrentState().Err, ErrEventTooLarge)
+		assertStates(t, []ReadyState{Connecting, Open, Closed}, sut)
+	})
+}
+
+func TestEventSource_WhenInvalidUTF8Rejected_ThenCloses(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithDecoderOptions(decoder.WithInvalidUTF8(decoder.InvalidUTF8Reject)), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Data: "invalid \xff"})
+
+		assertNoReceives(t, sut)
+		assert.ErrorIs(t, sut.CurrentState().Err, ErrInvalidUTF8)
+		assertStates(t, []ReadyState{Connecting, Open, Closed}, sut)
+	})
+}
+
+func TestHTTPStatusError_Classification(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.StatusCodes = []int{http.StatusServiceUnavailable}
+
+		sut, err := New(handler.URL, WithFatalStatusCodes(http.StatusServiceUnavailable))
+		defer sut.Close()
+
+		var statusErr *HTTPStatusError
+		if assert.ErrorAs(t, err, &statusErr) {
+			assert.False(t, statusErr.Temporary())
+			assert.True(t, statusErr.Fatal())
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package eventsource
 
 import (
+	"context"
 	"errors"
-	"log"
+	"io"
+	"log/slog"
 	"mime"
 	"net/http"
 	"sync"
@@ -21,86 +23,163 @@ var (
 	// ErrContentType means the content-type header of the server is not the
 	// expected one for an event-source. EventSource always expects
 	// `text/event-stream`. content-type.
-	ErrContentType = errors.New("eventsource: the content type of the stream is not allowed")
+	ErrContentType = fatalKind("eventsource: the content type of the stream is not allowed")
 
 	// ErrUnauthorized means the server responded with an authorization error
-	// status code.
-	ErrUnauthorized = errors.New("eventsource: connection is unauthorized")
+	// status code. The returned error is an HTTPStatusError which matches
+	// ErrUnauthorized with errors.Is.
+	ErrUnauthorized = fatalKind("eventsource: connection is unauthorized")
 )
 
 // EventSource connects and processes events from an HTTP server-sent
 // events stream.
 type EventSource struct {
+	ctx              context.Context
+	cancel           context.CancelFunc
 	readyState       chan Status
+	onStatus         func(status Status)
 	out              chan *base.MessageEvent
-	url              string
-	lastEventID      string
 	requestModifiers []RequestModifier
+	method           string
+	body             BodyFunc
+	client           *http.Client
+	transport        http.RoundTripper
+	backoff          BackoffPolicy
+	retryBounds      struct{ min, max time.Duration }
+	maxRetryAfter    time.Duration
+	idleTimeout      time.Duration
+	resolver         EndpointResolver
+	endpoint         string
+	redirects        redirectPolicy
 	decoder          *decoder.Decoder
+	decoderOptions   []decoder.Option
+	listeners        listeners
+	overflow         overflow
+	checkpoint       checkpoint
+	rotation         rotation
+	logger           *slog.Logger
+	observers        observers
+
+	retryableStatusCodes map[int]bool
 
 	safe struct {
 		sync.RWMutex
-		resp *http.Response
+		resp        *http.Response
+		url         string
+		lastEventID string
+		status      Status
+
+		// IDs of events received while rotating the connection, and
+		// the ones that the replacement connection must discard
+		seen  map[string]struct{}
+		dedup map[string]struct{}
 	}
 
+	// notify serializes the delivery of ready state changes
+	notify sync.Mutex
+
 	close struct {
 		sync.Once
-		notify    chan struct{}
 		completed chan struct{}
 		closed    uint32
+		err       error
 	}
+
+	// open is only accessed by the consumer goroutine
+	open bool
 }
 
-// New EventSource, it accepts requests modifiers which allow to modify the
-// underlying HTTP request, see RequestModifier.
-func New(url string, requestModifiers ...RequestModifier) (*EventSource, error) {
+// New EventSource, it accepts options which allow to configure the event
+// source and modify the underlying HTTP request, see Option and
+// RequestModifier.
+func New(url string, opts ...Option) (*EventSource, error) {
+	return NewWithContext(context.Background(), url, opts...)
+}
+
+// NewWithContext creates an EventSource bound to the lifetime of ctx.
+// Canceling ctx cancels any in-flight request, interrupts the reconnection
+// delay and closes the EventSource, the same way Close does.
+func NewWithContext(ctx context.Context, url string, opts ...Option) (*EventSource, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	es := &EventSource{
-		url:        url,
-		out:        make(chan *base.MessageEvent),
-		readyState: make(chan Status, 128),
-
-		safe: struct {
-			sync.RWMutex
-			resp *http.Response
-		}{},
+		ctx:      ctx,
+		cancel:   cancel,
+		out:      make(chan *base.MessageEvent),
+		method:   http.MethodGet,
+		rotation: rotation{next: make(chan handoff, 1)},
+		client:   http.DefaultClient,
+		backoff:  ConstantBackoff{},
+		logger:   slog.Default(),
+		redirects: redirectPolicy{
+			max:              defaultMaxRedirects,
+			allowCrossOrigin: true,
+		},
+		maxRetryAfter:        defaultMaxRetryAfter,
+		retryableStatusCodes: map[int]bool{},
+
 		close: struct {
 			sync.Once
-			notify    chan struct{}
 			completed chan struct{}
 			closed    uint32
+			err       error
 		}{
-			notify:    make(chan struct{}, 1),
-			completed: make(chan struct{}, 1),
+			completed: make(chan struct{}),
 		},
 	}
-	es.requestModifiers = append(es.requestModifiers, requestModifiers...)
+	for _, code := range defaultRetryableStatusCodes {
+		es.retryableStatusCodes[code] = true
+	}
+	es.safe.url = url
+	for _, opt := range opts {
+		opt.apply(es)
+	}
+	es.client = withRedirectPolicy(es.client, es.redirects)
+	if es.transport != nil {
+		es.client.Transport = es.transport
+	}
+	es.decoder = es.newDecoder(http.NoBody)
 
 	initialConn := make(chan error)
 	go es.consumer(initialConn)
 	return es, <-initialConn
 }
 
-// URL of the EventSource.
+// URL of the last connection established by the EventSource. It differs
+// from the URL given to New when the server redirects the connection, in
+// which case reconnections also use the redirected URL, or when an
+// EndpointResolver is used.
 func (es *EventSource) URL() string {
-	return es.url
+	es.safe.RLock()
+	defer es.safe.RUnlock()
+	return es.safe.url
 }
 
 // MessageEvents returns a receive-only channel where events are received.
+// Events handled by listeners, see AddEventListener, are not received.
 func (es *EventSource) MessageEvents() <-chan *base.MessageEvent {
 	return es.out
 }
 
 // ReadyState exposes a channel with updates on the ready state of
-// the EventSource. It must be consumed together with MessageEvents.
+// the EventSource. It is nil unless WithReadyStateChannel is used, in which
+// case it must be consumed together with MessageEvents.
 func (es *EventSource) ReadyState() <-chan Status {
 	return es.readyState
 }
 
+// CurrentState returns the latest ready state of the EventSource, without
+// blocking.
+func (es *EventSource) CurrentState() Status {
+	es.safe.RLock()
+	defer es.safe.RUnlock()
+	return es.safe.status
+}
+
 // Close the event source.
 // Once it has been closed, the event source cannot be re-used again.
 func (es *EventSource) Close() {
-	es.doClose(nil)
-	es.close.notify <- struct{}{}
+	es.doClose(ErrClosed)
+	es.cancel()
 	<-es.close.completed
 }
 
@@ -111,24 +190,121 @@ func (es *EventSource) doClose(err error) {
 	}
 
 	es.close.Do(func() {
+		// err must be set before closed, the consumer reads it once it
+		// sees the EventSource closed
+		es.close.err = err
 		atomic.AddUint32(&es.close.closed, 1)
-		es.readyState <- Status{ReadyState: Closed, Err: err}
+		es.setState(Status{ReadyState: Closed, Err: err})
 	})
 }
 
-func (es *EventSource) connect() (err error) {
-	es.readyState <- Status{ReadyState: Connecting, Err: nil}
-	resp, err := es.doHTTPConnect()
+// setState notifies the ready state, changes that race with Close after the
+// Closed state has been notified are ignored. Changes are delivered one at a
+// time, in the order they are stored. notify is held while the status handler
+// runs, which is why the handler must not call Close.
+func (es *EventSource) setState(status Status) {
+	es.notify.Lock()
+	defer es.notify.Unlock()
+
+	es.safe.Lock()
+	if es.safe.status.ReadyState == Closed {
+		es.safe.Unlock()
+		return
+	}
+	es.safe.status = status
+	es.safe.Unlock()
+
+	es.logger.Debug("eventsource: ready state changed",
+		slog.String("state", status.ReadyState.String()),
+		slog.Any("err", status.Err),
+	)
+
+	if es.onStatus != nil {
+		es.onStatus(status)
+	}
+	if es.readyState != nil {
+		es.readyState <- status
+	}
+}
+
+func (es *EventSource) newDecoder(body io.Reader) *decoder.Decoder {
+	opts := append([]decoder.Option{decoder.WithRetryHandler(es.onRetry)}, es.decoderOptions...)
+	return decoder.New(body, opts...)
+}
+
+func (es *EventSource) onRetry(change decoder.RetryChange) {
+	es.logger.Debug("eventsource: retry updated", slog.Duration("retry", change.Retry))
+}
+
+// connect reports the error that caused the connection attempt, if any,
+// along the Connecting state.
+func (es *EventSource) connect(cause error) (err error) {
+	es.setState(Status{ReadyState: Connecting, Err: cause})
+	endpoint, url, err := es.resolve()
 	if err != nil {
+		es.logger.Debug("eventsource: cannot resolve endpoint", slog.Any("err", err))
 		return
 	}
-	es.readyState <- Status{ReadyState: Open, Err: nil}
+	return es.dial(endpoint, url)
+}
+
+// connectFirst makes the first connection, with a resolver every endpoint is
+// tried at most once, so New returns the last error instead of retrying
+// forever.
+func (es *EventSource) connectFirst() (err error) {
+	tried := make(map[string]bool)
+	for {
+		es.setState(Status{ReadyState: Connecting, Err: err})
+		endpoint, url, resolveErr := es.resolve()
+		if resolveErr != nil {
+			es.logger.Debug("eventsource: cannot resolve endpoint", slog.Any("err", resolveErr))
+			return resolveErr
+		}
+		if tried[endpoint] {
+			return err
+		}
+		tried[endpoint] = true
+		err = es.dial(endpoint, url)
+		if err == nil || es.resolver == nil || !es.mustReconnect(err) {
+			return err
+		}
+	}
+}
+
+// dial connects to the URL resolved for the endpoint.
+func (es *EventSource) dial(endpoint, url string) (err error) {
+	es.logger.Debug("eventsource: connecting", slog.String("url", url))
+	es.observers.OnConnectAttempt(url)
+	resp, err := es.doHTTPConnect(url, es.getLastEventID())
+	if es.resolver != nil {
+		es.resolver.Report(endpoint, err)
+	}
+	if err != nil {
+		es.logger.Debug("eventsource: connection failed", slog.Any("err", err))
+		return
+	}
+	es.endpoint = endpoint
+	if es.idleTimeout > 0 {
+		resp.Body = newIdleReader(resp.Body, es.idleTimeout)
+	}
+	es.setState(Status{ReadyState: Open, Err: nil})
 	es.setResp(resp)
+	es.open = true
+	es.observers.OnConnected(resp)
+	es.scheduleRotation(resp)
 	return
 }
 
-func (es *EventSource) doHTTPConnect() (*http.Response, error) {
-	req, err := http.NewRequest("GET", es.url, nil)
+func (es *EventSource) doHTTPConnect(url, lastEventID string) (*http.Response, error) {
+	var body io.Reader
+	if es.body != nil {
+		var err error
+		if body, err = es.body(lastEventID); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(es.ctx, es.method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -139,33 +315,52 @@ func (es *EventSource) doHTTPConnect() (*http.Response, error) {
 
 	req.Header.Set("Accept", ContentType)
 	req.Header.Set("Cache-Control", "no-store")
-	if es.lastEventID != "" {
-		req.Header.Set("Last-Event-ID", es.lastEventID)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := es.client.Do(req)
 	if err != nil {
-		return resp, err
+		return nil, classifyConnectError(err)
 	}
 
-	if resp.StatusCode == 401 {
-		return resp, ErrUnauthorized
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		statusErr := newHTTPStatusError(resp)
+		statusErr.retryable = es.retryableStatusCodes[resp.StatusCode]
+		return nil, statusErr
 	}
 
 	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if err != nil || mediaType != ContentType {
-		return resp, ErrContentType
+		resp.Body.Close()
+		return nil, ErrContentType
 	}
+
+	es.safe.Lock()
+	es.safe.url = resp.Request.URL.String()
+	es.safe.Unlock()
 	return resp, nil
 }
 
 func (es *EventSource) consumer(initialConn chan error) {
 	defer func() {
+		es.cancel()
+		if h, ok := es.stopRotation(); ok {
+			h.resp.Body.Close()
+		}
+		if es.open {
+			es.observers.OnDisconnected(es.close.err)
+		}
+		es.observers.OnClosed(es.close.err)
 		close(es.out)
-		es.close.completed <- struct{}{}
+		close(es.close.completed)
 	}()
 
-	err := es.connect()
+	err := es.loadCheckpoint()
+	if err == nil {
+		err = es.connectFirst()
+	}
 	if err != nil {
 		es.doClose(err)
 		initialConn <- err
@@ -173,59 +368,129 @@ func (es *EventSource) consumer(initialConn chan error) {
 	}
 	initialConn <- nil
 
-	es.decoder = decoder.New(es.getResp().Body)
+	es.decoder.Reset(es.getResp().Body)
 	for {
+		lastEventID := es.decoderLastEventID()
 		ev, err := es.decoder.Decode()
 		if err != nil {
-			for es.mustReconnect(err) {
-				time.Sleep(es.decoder.Retry())
-				err = es.connect()
+			es.syncLastEventID(lastEventID)
+			err = classifyDecodeError(err)
+			if body, ok := es.getResp().Body.(*idleReader); ok && body.Expired() {
+				err = ErrIdleTimeout
+			}
+			if h, ok := es.stopRotation(); ok {
+				es.handOver(h, err)
+				continue
 			}
+			es.open = false
+			es.observers.OnDisconnected(err)
+			err = es.reconnect(err)
 			if es.isClosed() {
 				return
+			} else if ctxErr := es.ctx.Err(); ctxErr != nil {
+				es.doClose(ctxErr)
+				return
 			} else if err != nil {
 				es.doClose(err)
 				return
 			}
-			es.decoder = decoder.New(es.getResp().Body)
+			es.decoder.Reset(es.getResp().Body)
 			continue
 		}
 
-		if ev.HasID {
-			es.lastEventID = ev.ID
+		if !es.track(ev) {
+			continue
 		}
+		es.syncLastEventID(lastEventID)
+		es.observers.OnEvent(ev, eventSize(ev))
 
-		var sent bool
-		for !sent {
-			select {
-			case <-es.close.notify:
-				return
-			case es.out <- ev:
-				sent = true
-			case <-time.After(1 * time.Second):
-				log.Printf("eventsource: slow consumer, messages are not being consumed")
+		if !es.dispatch(ev) && !es.send(ev) {
+			return
+		}
+		es.commitCheckpoint()
+	}
+}
+
+// handOver switches to the replacement connection of a rotation.
+func (es *EventSource) handOver(h handoff, err error) {
+	es.logger.Debug("eventsource: connection rotated", slog.String("url", h.resp.Request.URL.String()))
+	es.observers.OnDisconnected(err)
+	es.observers.OnConnectAttempt(h.resp.Request.URL.String())
+	es.observers.OnConnected(h.resp)
+
+	es.safe.Lock()
+	es.safe.dedup = es.safe.seen
+	es.safe.seen = nil
+	es.safe.Unlock()
+
+	es.endpoint = h.endpoint
+	es.setResp(h.resp)
+	es.decoder.Reset(h.resp.Body)
+	es.scheduleRotation(h.resp)
+}
+
+// reconnect attempts to reconnect for as long as the error allows it, it
+// returns the last error.
+func (es *EventSource) reconnect(err error) error {
+	var attempt int
+	var delay time.Duration
+	for es.mustReconnect(err) {
+		attempt++
+		delay = es.backoff.Delay(attempt, es.retry(), delay)
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			if retryAfter, ok := statusErr.RetryAfter(); ok {
+				delay = clampRetry(retryAfter, 0, es.maxRetryAfter)
+				delay = clampRetry(delay, 0, es.retryBounds.max)
 			}
 		}
+		es.logger.Debug("eventsource: reconnecting",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("err", err),
+		)
+		es.observers.OnRetryScheduled(delay)
+		if !es.wait(delay) {
+			break
+		}
+		err = es.connect(err)
 	}
+	return err
 }
 
 func (es *EventSource) mustReconnect(err error) bool {
-	if es.isClosed() {
+	if es.isClosed() || es.ctx.Err() != nil {
 		return false
 	}
 
-	resp := es.getResp()
-	if resp != nil && resp.StatusCode == http.StatusNoContent {
+	var classified interface{ Fatal() bool }
+	switch {
+	case err == nil:
 		return false
+	case errors.As(err, &classified):
+		return !classified.Fatal()
+	default:
+		return true
 	}
+}
 
-	switch err {
-	case nil:
-		return false
-	case ErrContentType:
-		return false
-	default:
+// retry returns the reconnection time advertised by the server, clamped to
+// the configured bounds.
+func (es *EventSource) retry() time.Duration {
+	return clampRetry(es.decoder.Retry(), es.retryBounds.min, es.retryBounds.max)
+}
+
+// wait blocks for the given delay, it returns false if the EventSource
+// context is done before the delay elapses.
+func (es *EventSource) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
 		return true
+	case <-es.ctx.Done():
+		return false
 	}
 }
 
@@ -241,6 +506,35 @@ func (es *EventSource) getResp() *http.Response {
 	return es.safe.resp
 }
 
+func (es *EventSource) setLastEventID(id string) {
+	es.safe.Lock()
+	defer es.safe.Unlock()
+	es.safe.lastEventID = id
+}
+
+// decoderLastEventID returns the last event ID buffer of the decoder in spec
+// mode, where events that are not dispatched also change it.
+func (es *EventSource) decoderLastEventID() string {
+	if !es.decoder.SpecMode() {
+		return ""
+	}
+	return es.decoder.LastEventID()
+}
+
+// syncLastEventID reconnects from the last event ID buffer of the decoder,
+// when it changed since previous, as browsers do in spec mode.
+func (es *EventSource) syncLastEventID(previous string) {
+	if id := es.decoderLastEventID(); id != previous {
+		es.setLastEventID(id)
+	}
+}
+
+func (es *EventSource) getLastEventID() string {
+	es.safe.RLock()
+	defer es.safe.RUnlock()
+	return es.safe.lastEventID
+}
+
 func (es *EventSource) isClosed() bool {
 	return atomic.LoadUint32(&es.close.closed) > 0
 }
@@ -1,11 +1,17 @@
 package eventsource
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alevinval/sse/internal/testutils"
 	"github.com/alevinval/sse/internal/testutils/server"
 	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/encoder"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,7 +36,7 @@ func TestEventSource_WhenURL_ThenMatches(t *testing.T) {
 
 func TestEventSource_WhenConnectAndClose_ThenReadyStatesMatch(t *testing.T) {
 	setUp(t, func(handler *server.MockHandler) {
-		sut, _ := New(handler.URL)
+		sut, _ := New(handler.URL, WithReadyStateChannel(128))
 
 		<-handler.Connected
 		sut.Close()
@@ -49,10 +55,56 @@ func TestEventSource_WhenClose_ThenChannelIsClosed(t *testing.T) {
 	})
 }
 
+func TestEventSource_WhenContextCanceled_ThenChannelIsClosed(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		ctx, cancel := context.WithCancel(context.Background())
+		sut, _ := NewWithContext(ctx, handler.URL, WithReadyStateChannel(128))
+
+		<-handler.Connected
+		cancel()
+
+		assertNoReceives(t, sut)
+		assertStates(t, []ReadyState{Connecting, Open, Closed}, sut)
+	})
+}
+
+func TestEventSource_WhenContextCanceledWhileWaiting_ThenStopsReconnecting(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		ctx, cancel := context.WithCancel(context.Background())
+		sut, _ := NewWithContext(ctx, handler.URL)
+
+		<-handler.Connected
+		handler.WriteRetry(5000, sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		cancel()
+
+		select {
+		case _, ok := <-sut.MessageEvents():
+			assert.False(t, ok)
+		case <-time.After(100 * time.Millisecond):
+			assert.Fail(t, "event source did not stop waiting to reconnect")
+		}
+	})
+}
+
+func TestEventSource_WhenContextCanceled_ThenCloseDoesNotBlock(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		ctx, cancel := context.WithCancel(context.Background())
+		sut, _ := NewWithContext(ctx, handler.URL)
+
+		<-handler.Connected
+		cancel()
+		assertNoReceives(t, sut)
+
+		sut.Close()
+		sut.Close()
+	})
+}
+
 func TestEventSource_WhenInvalidContentType_ThenReturnsError(t *testing.T) {
 	setUp(t, func(handler *server.MockHandler) {
 		handler.ContentType = "text/plain; charset=utf-8"
-		sut, err := New(handler.URL)
+		sut, err := New(handler.URL, WithReadyStateChannel(128))
 
 		assert.Equal(t, ErrContentType, err)
 		assertStates(t, []ReadyState{Connecting, Closed}, sut)
@@ -93,7 +145,7 @@ func TestEventSource_WhenMultipleWrites_ThenKeepsLastEventID(t *testing.T) {
 		handler.WriteRetry(1, sut.getDecoder)
 		handler.WriteEvent(eventWithID)
 		assertReceive(t, sut, eventWithID)
-		assert.Equal(t, "event-id", sut.lastEventID)
+		assert.Equal(t, "event-id", sut.getLastEventID())
 
 		// Force reconnection, which should set Last-Event-ID header
 		handler.CloseActiveRequest(true)
@@ -101,7 +153,7 @@ func TestEventSource_WhenMultipleWrites_ThenKeepsLastEventID(t *testing.T) {
 
 		handler.WriteEvent(eventWithoutID)
 		assertReceive(t, sut, eventWithoutID)
-		assert.Equal(t, "event-id", sut.lastEventID)
+		assert.Equal(t, "event-id", sut.getLastEventID())
 	})
 }
 
@@ -120,11 +172,11 @@ func TestEventSource_WhenMultipleWrites_ThenResetsLastEventID(t *testing.T) {
 		<-handler.Connected
 		handler.WriteEvent(eventWithID)
 		assertReceive(t, sut, eventWithID)
-		assert.Equal(t, "event-id", sut.lastEventID)
+		assert.Equal(t, "event-id", sut.getLastEventID())
 
 		handler.WriteEvent(eventWithEmptyID)
 		assertReceive(t, sut, eventWithEmptyID)
-		assert.Equal(t, "", sut.lastEventID)
+		assert.Equal(t, "", sut.getLastEventID())
 	})
 }
 
@@ -134,7 +186,7 @@ func TestEventSource_WhenReconnecting_RetryIsRespected(t *testing.T) {
 	setUp(t, func(handler *server.MockHandler) {
 		handler.MaxRequestsToProcess = 3
 
-		sut, _ := New(handler.URL)
+		sut, _ := New(handler.URL, WithReadyStateChannel(128))
 		defer sut.Close()
 
 		<-handler.Connected
@@ -154,11 +206,29 @@ func TestEventSource_WhenReconnecting_RetryIsRespected(t *testing.T) {
 	})
 }
 
-func TestEventSource_WhenConnectionDropped_CannotReconnect(t *testing.T) {
+func TestEventSource_WhenReconnecting_RetryIsKept(t *testing.T) {
+	delay := 50 * time.Millisecond
 	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 3
+
 		sut, _ := New(handler.URL)
 		defer sut.Close()
 
+		<-handler.Connected
+		handler.WriteRetry(int(delay.Milliseconds()), sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		assertConnectionWithinDeadline(t, handler, delay, 2*delay)
+
+		handler.CloseActiveRequest(true)
+		assertConnectionWithinDeadline(t, handler, delay, 2*delay)
+	})
+}
+
+func TestEventSource_WhenConnectionDropped_CannotReconnect(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithReadyStateChannel(128))
+		defer sut.Close()
+
 		<-handler.Connected
 		handler.WriteRetry(1, sut.getDecoder)
 		handler.CloseActiveRequest(true)
@@ -166,7 +236,7 @@ func TestEventSource_WhenConnectionDropped_CannotReconnect(t *testing.T) {
 		assertNoReceives(t, sut)
 		assertStates(
 			t,
-			[]ReadyState{Connecting, Open, Connecting, Open, Closed},
+			[]ReadyState{Connecting, Open, Connecting, Closed},
 			sut,
 		)
 	})
@@ -175,7 +245,7 @@ func TestEventSource_WhenConnectionDropped_CannotReconnect(t *testing.T) {
 func TestEventSource_DropConnection_CanReconnect(t *testing.T) {
 	setUp(t, func(handler *server.MockHandler) {
 		handler.MaxRequestsToProcess = 2
-		sut, _ := New(handler.URL)
+		sut, _ := New(handler.URL, WithReadyStateChannel(128))
 		defer sut.Close()
 
 		<-handler.Connected
@@ -191,6 +261,75 @@ func TestEventSource_DropConnection_CanReconnect(t *testing.T) {
 	})
 }
 
+func TestEventSource_CurrentState(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL)
+
+		<-handler.Connected
+		assert.Equal(t, Status{ReadyState: Open}, sut.CurrentState())
+
+		sut.Close()
+		assert.Equal(t, Status{ReadyState: Closed, Err: ErrClosed}, sut.CurrentState())
+		assert.Nil(t, sut.ReadyState())
+	})
+}
+
+func TestEventSource_WithStatusHandler(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		states := make(chan Status, 8)
+		sut, _ := New(handler.URL, WithStatusHandler(func(status Status) { states <- status }))
+
+		<-handler.Connected
+		sut.Close()
+
+		assert.Equal(t, []ReadyState{Connecting, Open, Closed}, collectStates(states))
+	})
+}
+
+func TestEventSource_WhenReadyStateNotConsumed_ThenDoesNotBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", ContentType)
+		encoder.New(rw).WriteRetry(0)
+	}))
+	defer server.Close()
+
+	changes := new(atomic.Int32)
+	sut, err := New(server.URL, WithStatusHandler(func(Status) { changes.Add(1) }))
+	assert.NoError(t, err)
+
+	testutils.ExpectCondition(t, func() bool {
+		return changes.Load() > 300
+	})
+	sut.Close()
+	assert.Equal(t, Closed, sut.CurrentState().ReadyState)
+}
+
+func TestEventSource_WithStatusHandler_WhenClosedConcurrently_ThenSerializesCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", ContentType)
+		encoder.New(rw).WriteRetry(0)
+	}))
+	defer server.Close()
+
+	var inFlight, overlaps atomic.Int32
+	var last atomic.Value
+	sut, err := New(server.URL, WithStatusHandler(func(status Status) {
+		if inFlight.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(time.Microsecond)
+		last.Store(status.ReadyState)
+		inFlight.Add(-1)
+	}))
+	assert.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	sut.Close()
+
+	assert.Zero(t, overlaps.Load())
+	assert.Equal(t, Closed, last.Load())
+}
+
 func TestEventSource_WithBasicAuth(t *testing.T) {
 	setUp(t, func(handler *server.MockHandler) {
 		handler.BasicAuth.Username = "foo"
@@ -209,7 +348,7 @@ func TestEventSource_WithBasicAuth_InvalidPassword(t *testing.T) {
 		handler.BasicAuth.Password = "bar"
 
 		sut, err := New(handler.URL, WithBasicAuth("foo", ""))
-		assert.Equal(t, ErrUnauthorized, err)
+		assert.ErrorIs(t, err, ErrUnauthorized)
 
 		sut.Close()
 	})
@@ -0,0 +1,107 @@
+package eventsource
+
+import (
+	"sync"
+	"time"
+)
+
+var _ (EndpointResolver) = (*Failover)(nil)
+
+// ErrNoEndpoints means there is no endpoint to connect to.
+var ErrNoEndpoints = fatalKind("eventsource: no endpoints to connect to")
+
+// EndpointResolver chooses the URL of every connection attempt, see
+// WithEndpointResolver.
+type EndpointResolver interface {
+	// Resolve returns the URL for the next connection attempt.
+	Resolve() (string, error)
+	// Report the outcome of connecting to the URL, err is nil on success.
+	Report(url string, err error)
+}
+
+// ResolverFunc adapts a function into an EndpointResolver that ignores
+// reports.
+type ResolverFunc func() (string, error)
+
+// Resolve calls the function.
+func (fn ResolverFunc) Resolve() (string, error) {
+	return fn()
+}
+
+// Report does nothing.
+func (fn ResolverFunc) Report(string, error) {}
+
+// Failover connects to the first URL, the primary, and moves to the next
+// URL whenever connecting fails. After failing over, the primary is tried
+// again once the cool-down elapses, replacing a healthy connection to a
+// fallback the same way WithMaxConnectionLifetime does.
+type Failover struct {
+	mu           sync.Mutex
+	urls         []string
+	cooldown     time.Duration
+	current      int
+	failedOverAt time.Time
+}
+
+// NewFailover returns a Failover for the URLs, ordered by preference. A zero
+// cool-down never returns to the primary until the others fail.
+func NewFailover(cooldown time.Duration, urls ...string) *Failover {
+	return &Failover{urls: urls, cooldown: cooldown}
+}
+
+// Resolve returns the URL in use, or the primary once the cool-down elapsed.
+func (f *Failover) Resolve() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.urls) == 0 {
+		return "", ErrNoEndpoints
+	}
+	if f.current != 0 && f.cooldown > 0 && time.Since(f.failedOverAt) >= f.cooldown {
+		f.current = 0
+	}
+	return f.urls[f.current], nil
+}
+
+// Report moves to the next URL when connecting to the URL in use failed.
+func (f *Failover) Report(url string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil || len(f.urls) == 0 || url != f.urls[f.current] {
+		return
+	}
+	if f.current == 0 {
+		f.failedOverAt = time.Now()
+	}
+	f.current = (f.current + 1) % len(f.urls)
+}
+
+// failbackIn returns how long until the primary is tried again, false when
+// the primary is in use or there is no cool-down.
+func (f *Failover) failbackIn() (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.current == 0 || f.cooldown <= 0 {
+		return 0, false
+	}
+	return max(f.cooldown-time.Since(f.failedOverAt), 0), true
+}
+
+// resolve returns the endpoint and the URL to connect to, which differ when
+// the endpoint was redirected.
+func (es *EventSource) resolve() (endpoint string, url string, err error) {
+	if es.resolver == nil {
+		return "", es.URL(), nil
+	}
+
+	endpoint, err = es.resolver.Resolve()
+	if err != nil {
+		return "", "", err
+	}
+	if endpoint == es.endpoint {
+		return endpoint, es.URL(), nil
+	}
+	return endpoint, endpoint, nil
+}
@@ -0,0 +1,141 @@
+package eventsource
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils"
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailover_WhenFails_ThenMovesToNext(t *testing.T) {
+	sut := NewFailover(0, "primary", "secondary")
+
+	assertResolves(t, "primary", sut)
+	sut.Report("primary", errors.New("failed"))
+	assertResolves(t, "secondary", sut)
+	sut.Report("secondary", nil)
+	assertResolves(t, "secondary", sut)
+	sut.Report("secondary", errors.New("failed"))
+	assertResolves(t, "primary", sut)
+}
+
+func TestFailover_WhenReportIsStale_ThenIgnored(t *testing.T) {
+	sut := NewFailover(0, "primary", "secondary")
+
+	sut.Report("secondary", errors.New("failed"))
+
+	assertResolves(t, "primary", sut)
+}
+
+func TestFailover_WhenCooldownElapses_ThenReturnsToPrimary(t *testing.T) {
+	sut := NewFailover(20*time.Millisecond, "primary", "secondary")
+
+	sut.Report("primary", errors.New("failed"))
+	assertResolves(t, "secondary", sut)
+
+	time.Sleep(20 * time.Millisecond)
+	assertResolves(t, "primary", sut)
+}
+
+func TestFailover_WhenNoURLs_ThenReturnsError(t *testing.T) {
+	_, err := NewFailover(0).Resolve()
+
+	assert.Equal(t, ErrNoEndpoints, err)
+}
+
+func TestEventSource_WithFailover_WhenPrimaryFails_ThenConnectsToSecondary(t *testing.T) {
+	setUp(t, func(primary *server.MockHandler) {
+		setUp(t, func(secondary *server.MockHandler) {
+			primary.StatusCodes = []int{http.StatusServiceUnavailable}
+
+			sut, err := New(primary.URL, WithFailover(0, secondary.URL), WithRetryBounds(0, time.Millisecond))
+			assert.NoError(t, err)
+			defer sut.Close()
+
+			<-secondary.Connected
+			assert.Equal(t, secondary.URL, sut.URL())
+		})
+	})
+}
+
+func TestEventSource_WithFailover_WhenAllFail_ThenNewReturnsError(t *testing.T) {
+	setUp(t, func(primary *server.MockHandler) {
+		setUp(t, func(secondary *server.MockHandler) {
+			primary.StatusCodes = []int{http.StatusServiceUnavailable}
+			secondary.StatusCodes = []int{http.StatusServiceUnavailable}
+
+			sut, err := New(primary.URL, WithFailover(0, secondary.URL))
+
+			assert.Equal(t, Closed, sut.CurrentState().ReadyState)
+			var statusErr *HTTPStatusError
+			if assert.ErrorAs(t, err, &statusErr) {
+				assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+			}
+		})
+	})
+}
+
+func TestEventSource_WithFailover_ThenCarriesLastEventID(t *testing.T) {
+	setUp(t, func(primary *server.MockHandler) {
+		setUp(t, func(secondary *server.MockHandler) {
+			sut, _ := New(primary.URL, WithFailover(0, secondary.URL))
+			defer sut.Close()
+
+			<-primary.Connected
+			primary.WriteRetry(1, sut.getDecoder)
+			primary.WriteEvent(&base.MessageEvent{ID: "event-id"})
+			assertReceive(t, sut, &base.MessageEvent{ID: "event-id"})
+
+			primary.StatusCodes = []int{http.StatusBadGateway}
+			secondary.ExpectLastEventID("event-id")
+			primary.CloseActiveRequest(true)
+
+			<-secondary.Connected
+			testutils.ExpectCondition(t, func() bool { return sut.URL() == secondary.URL })
+		})
+	})
+}
+
+func TestEventSource_WithFailover_WhenCooldownElapses_ThenFailsBackToPrimary(t *testing.T) {
+	setUp(t, func(primary *server.MockHandler) {
+		setUp(t, func(secondary *server.MockHandler) {
+			primary.StatusCodes = []int{http.StatusServiceUnavailable}
+
+			sut, err := New(primary.URL, WithFailover(50*time.Millisecond, secondary.URL))
+			assert.NoError(t, err)
+			defer sut.Close()
+
+			<-secondary.Connected
+			assert.Equal(t, secondary.URL, sut.URL())
+
+			<-primary.Connected
+			testutils.ExpectCondition(t, func() bool { return sut.URL() == primary.URL })
+			assert.Equal(t, Open, sut.CurrentState().ReadyState)
+		})
+	})
+}
+
+func TestEventSource_WithEndpointResolver(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		resolver := ResolverFunc(func() (string, error) { return handler.URL, nil })
+
+		sut, err := New("http://unused", WithEndpointResolver(resolver))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+		assert.Equal(t, handler.URL, sut.URL())
+	})
+}
+
+func assertResolves(t *testing.T, expected string, resolver EndpointResolver) {
+	actual, err := resolver.Resolve()
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, actual)
+	}
+}
@@ -0,0 +1,94 @@
+package eventsource
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Maximum amount of bytes of the response body kept in HTTPStatusError.
+const maxErrorBodySize = 512
+
+// Status codes for which the EventSource attempts to reconnect by default,
+// any other status code other than 200 closes the EventSource.
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// HTTPStatusError means the server responded with a status code other
+// than 200 OK.
+type HTTPStatusError struct {
+	StatusCode int
+	Header     http.Header
+
+	// Body holds the beginning of the response body, which is truncated
+	// to a few hundred bytes.
+	Body []byte
+
+	// retryable is set when the EventSource reconnects after the status.
+	retryable bool
+}
+
+func newHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("eventsource: unexpected status code %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is allows matching 401 Unauthorized responses with ErrUnauthorized.
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrUnauthorized && e.StatusCode == http.StatusUnauthorized
+}
+
+// Temporary reports whether the EventSource reconnects after the status
+// code, see WithRetryableStatusCodes.
+func (e *HTTPStatusError) Temporary() bool {
+	return e.retryable
+}
+
+// Fatal reports whether the status code closes the EventSource.
+func (e *HTTPStatusError) Fatal() bool {
+	return !e.retryable
+}
+
+// Maximum delay requested with Retry-After that is respected by default, see
+// WithMaxRetryAfter.
+const defaultMaxRetryAfter = 5 * time.Minute
+
+// RetryAfter returns the delay requested by the server with the Retry-After
+// header, either in seconds or as an HTTP date.
+func (e *HTTPStatusError) RetryAfter() (time.Duration, bool) {
+	value := e.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := time.Until(date); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
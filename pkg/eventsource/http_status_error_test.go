@@ -0,0 +1,155 @@
+package eventsource
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatusError_RetryAfter(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"invalid", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+	} {
+		sut := &HTTPStatusError{Header: http.Header{"Retry-After": []string{test.value}}}
+
+		actual, ok := sut.RetryAfter()
+
+		assert.Equal(t, test.expected, actual, test.value)
+		assert.Equal(t, test.ok, ok, test.value)
+	}
+}
+
+func TestHTTPStatusError_RetryAfterDate(t *testing.T) {
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	sut := &HTTPStatusError{Header: http.Header{"Retry-After": []string{date}}}
+
+	actual, ok := sut.RetryAfter()
+
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, actual, float64(2*time.Second))
+}
+
+func TestHTTPStatusError_IsUnauthorized(t *testing.T) {
+	assert.ErrorIs(t, &HTTPStatusError{StatusCode: http.StatusUnauthorized}, ErrUnauthorized)
+	assert.NotErrorIs(t, &HTTPStatusError{StatusCode: http.StatusForbidden}, ErrUnauthorized)
+}
+
+func TestEventSource_WhenStatusNotOK_ThenReturnsHTTPStatusError(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.StatusCodes = []int{http.StatusInternalServerError}
+
+		sut, err := New(handler.URL)
+		defer sut.Close()
+
+		var statusErr *HTTPStatusError
+		if assert.ErrorAs(t, err, &statusErr) {
+			assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+			assert.Equal(t, "Internal Server Error\n", string(statusErr.Body))
+		}
+		assert.NotErrorIs(t, err, ErrContentType)
+	})
+}
+
+func TestEventSource_WhenRetryableStatus_ThenRetryAfterIsRespected(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		// Second attempt would wait 5s without Retry-After
+		sut, _ := New(handler.URL, WithBackoff(ExponentialBackoff{Multiplier: 5000}), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.StatusCodes = []int{http.StatusServiceUnavailable}
+		handler.RetryAfter = "0"
+		handler.CloseActiveRequest(true)
+
+		assertConnectionWithinDeadline(t, handler, 0, 500*time.Millisecond)
+		assertStates(t, []ReadyState{Connecting, Open, Connecting, Connecting, Open}, sut)
+	})
+}
+
+func TestEventSource_WhenRetryAfterIsTooLong_ThenIsCapped(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		sut, _ := New(handler.URL, WithMaxRetryAfter(time.Millisecond), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.StatusCodes = []int{http.StatusServiceUnavailable}
+		handler.RetryAfter = "86400"
+		handler.CloseActiveRequest(true)
+		assertConnectionWithinDeadline(t, handler, 0, 500*time.Millisecond)
+	})
+}
+
+func TestEventSource_WhenRetryAfterExceedsRetryBounds_ThenIsCapped(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		sut, _ := New(handler.URL, WithRetryBounds(0, time.Millisecond), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.StatusCodes = []int{http.StatusServiceUnavailable}
+		handler.RetryAfter = "86400"
+		handler.CloseActiveRequest(true)
+		assertConnectionWithinDeadline(t, handler, 0, 500*time.Millisecond)
+	})
+}
+
+func TestEventSource_WhenFatalStatus_ThenCloses(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.StatusCodes = []int{http.StatusNotFound}
+		handler.CloseActiveRequest(true)
+
+		assertNoReceives(t, sut)
+		assertStates(t, []ReadyState{Connecting, Open, Connecting, Closed}, sut)
+	})
+}
+
+func TestEventSource_WithRetryableStatusCodes(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		sut, _ := New(handler.URL, WithRetryableStatusCodes(http.StatusNotFound), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.StatusCodes = []int{http.StatusNotFound}
+		handler.CloseActiveRequest(true)
+
+		<-handler.Connected
+		assertStates(t, []ReadyState{Connecting, Open, Connecting, Connecting, Open}, sut)
+	})
+}
+
+func TestEventSource_WithFatalStatusCodes(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithFatalStatusCodes(http.StatusServiceUnavailable), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.StatusCodes = []int{http.StatusServiceUnavailable}
+		handler.CloseActiveRequest(true)
+
+		assertNoReceives(t, sut)
+		assertStates(t, []ReadyState{Connecting, Open, Connecting, Closed}, sut)
+	})
+}
@@ -0,0 +1,52 @@
+package eventsource
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrIdleTimeout means no bytes were received from the server during the
+// idle timeout, hence the connection was considered dead and closed, see
+// WithIdleTimeout.
+var ErrIdleTimeout = temporaryKind("eventsource: connection idle timeout")
+
+// idleReader closes the underlying reader when a read waits longer than the
+// timeout. The timer only runs while a read is in flight, so time spent by
+// the consumer between reads does not count as inactivity.
+type idleReader struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	expired uint32
+}
+
+func newIdleReader(body io.ReadCloser, timeout time.Duration) *idleReader {
+	r := &idleReader{ReadCloser: body, timeout: timeout}
+	r.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreUint32(&r.expired, 1)
+		body.Close()
+	})
+	r.timer.Stop()
+	return r
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	r.timer.Reset(r.timeout)
+	n, err := r.ReadCloser.Read(p)
+	r.timer.Stop()
+	if err != nil && r.Expired() {
+		err = ErrIdleTimeout
+	}
+	return n, err
+}
+
+func (r *idleReader) Close() error {
+	r.timer.Stop()
+	return r.ReadCloser.Close()
+}
+
+// Expired returns true when the reader was closed due to inactivity.
+func (r *idleReader) Expired() bool {
+	return atomic.LoadUint32(&r.expired) > 0
+}
@@ -0,0 +1,89 @@
+package eventsource
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSource_WithIdleTimeout_ThenReconnects(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		sut, _ := New(handler.URL, WithIdleTimeout(50*time.Millisecond), WithRetryBounds(0, time.Millisecond), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		<-handler.Connected
+
+		assert.Equal(t, Status{ReadyState: Connecting}, <-sut.ReadyState())
+		assert.Equal(t, Status{ReadyState: Open}, <-sut.ReadyState())
+		assert.Equal(t, Status{ReadyState: Connecting, Err: ErrIdleTimeout}, <-sut.ReadyState())
+		assert.Equal(t, Status{ReadyState: Open}, <-sut.ReadyState())
+	})
+}
+
+func TestEventSource_WithIdleTimeout_ThenTrafficKeepsConnectionAlive(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithIdleTimeout(50*time.Millisecond), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		for i := 0; i < 5; i++ {
+			time.Sleep(20 * time.Millisecond)
+			handler.WriteEvent(&base.MessageEvent{Data: "data"})
+			assertReceive(t, sut, &base.MessageEvent{Data: "data"})
+		}
+
+		assertStates(t, []ReadyState{Connecting, Open}, sut)
+	})
+}
+
+func TestEventSource_WithIdleTimeout_WhenConsumerIsSlow_ThenKeepsConnection(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithIdleTimeout(50*time.Millisecond), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		go func() {
+			for i := 0; i < 40; i++ {
+				handler.WriteEvent(&base.MessageEvent{Data: "data"})
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+
+		assertReceive(t, sut, &base.MessageEvent{Data: "data"})
+		time.Sleep(200 * time.Millisecond)
+		for i := 1; i < 40; i++ {
+			assertReceive(t, sut, &base.MessageEvent{Data: "data"})
+		}
+
+		assertStates(t, []ReadyState{Connecting, Open}, sut)
+	})
+}
+
+type blockingReader struct {
+	closed chan struct{}
+}
+
+func (r *blockingReader) Read([]byte) (int, error) {
+	<-r.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (r *blockingReader) Close() error {
+	close(r.closed)
+	return nil
+}
+
+func TestIdleReader_WhenExpired_ThenReturnsErrIdleTimeout(t *testing.T) {
+	sut := newIdleReader(&blockingReader{closed: make(chan struct{})}, time.Millisecond)
+
+	_, err := sut.Read(make([]byte, 1))
+
+	assert.Equal(t, ErrIdleTimeout, err)
+	assert.True(t, sut.Expired())
+}
@@ -0,0 +1,81 @@
+package eventsource
+
+import (
+	"sync"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+// DefaultEventName is the name of events that do not specify one.
+const DefaultEventName = "message"
+
+// ListenerID identifies a listener added with AddEventListener.
+type ListenerID uint64
+
+type listener struct {
+	id ListenerID
+	fn func(*base.MessageEvent)
+}
+
+type listeners struct {
+	sync.RWMutex
+	next   ListenerID
+	byName map[string][]listener
+}
+
+// AddEventListener registers fn to be called for every event with the
+// given name, unnamed events are named "message". Listeners are called in
+// order of registration from the goroutine that reads the stream, hence they
+// must not block nor call Close. Events handled by at least one listener
+// are not sent to MessageEvents.
+func (es *EventSource) AddEventListener(name string, fn func(*base.MessageEvent)) ListenerID {
+	es.listeners.Lock()
+	defer es.listeners.Unlock()
+
+	if es.listeners.byName == nil {
+		es.listeners.byName = map[string][]listener{}
+	}
+	es.listeners.next++
+	id := es.listeners.next
+	es.listeners.byName[name] = append(es.listeners.byName[name], listener{id: id, fn: fn})
+	return id
+}
+
+// RemoveEventListener removes a listener added with AddEventListener.
+func (es *EventSource) RemoveEventListener(id ListenerID) {
+	es.listeners.Lock()
+	defer es.listeners.Unlock()
+
+	for name, registered := range es.listeners.byName {
+		for i, l := range registered {
+			if l.id != id {
+				continue
+			}
+			registered = append(registered[:i:i], registered[i+1:]...)
+			if len(registered) == 0 {
+				delete(es.listeners.byName, name)
+			} else {
+				es.listeners.byName[name] = registered
+			}
+			return
+		}
+	}
+}
+
+// dispatch calls the listeners registered for the event, it returns false
+// when there are none.
+func (es *EventSource) dispatch(ev *base.MessageEvent) bool {
+	name := ev.Name
+	if name == "" {
+		name = DefaultEventName
+	}
+
+	es.listeners.RLock()
+	registered := es.listeners.byName[name]
+	es.listeners.RUnlock()
+
+	for _, l := range registered {
+		l.fn(ev)
+	}
+	return len(registered) > 0
+}
@@ -0,0 +1,93 @@
+package eventsource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSource_AddEventListener_DispatchesByName(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL)
+		defer sut.Close()
+
+		named := make(chan *base.MessageEvent, 1)
+		unnamed := make(chan *base.MessageEvent, 1)
+		sut.AddEventListener("update", func(ev *base.MessageEvent) { named <- ev })
+		sut.AddEventListener(DefaultEventName, func(ev *base.MessageEvent) { unnamed <- ev })
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Name: "update", Data: "first"})
+		handler.WriteEvent(&base.MessageEvent{Data: "second"})
+		handler.WriteEvent(&base.MessageEvent{Name: "other", Data: "third"})
+
+		assert.Equal(t, "first", receiveEvent(t, named).Data)
+		assert.Equal(t, "second", receiveEvent(t, unnamed).Data)
+		assertReceive(t, sut, &base.MessageEvent{Name: "other", Data: "third"})
+	})
+}
+
+func TestEventSource_AddEventListener_CallsAllListeners(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL)
+		defer sut.Close()
+
+		received := make(chan *base.MessageEvent, 2)
+		sut.AddEventListener("update", func(ev *base.MessageEvent) { received <- ev })
+		sut.AddEventListener("update", func(ev *base.MessageEvent) { received <- ev })
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Name: "update", Data: "data"})
+
+		assert.Equal(t, "data", receiveEvent(t, received).Data)
+		assert.Equal(t, "data", receiveEvent(t, received).Data)
+	})
+}
+
+func TestEventSource_RemoveEventListener(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL)
+		defer sut.Close()
+
+		removed := make(chan *base.MessageEvent, 1)
+		kept := make(chan *base.MessageEvent, 1)
+		id := sut.AddEventListener("update", func(ev *base.MessageEvent) { removed <- ev })
+		sut.AddEventListener("update", func(ev *base.MessageEvent) { kept <- ev })
+		sut.RemoveEventListener(id)
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Name: "update", Data: "data"})
+
+		assert.Equal(t, "data", receiveEvent(t, kept).Data)
+		assert.Empty(t, removed)
+	})
+}
+
+func TestEventSource_RemoveEventListener_FallsBackToChannel(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL)
+		defer sut.Close()
+
+		id := sut.AddEventListener("update", func(ev *base.MessageEvent) {})
+		sut.RemoveEventListener(id)
+
+		<-handler.Connected
+		expected := &base.MessageEvent{Name: "update", Data: "data"}
+		handler.WriteEvent(expected)
+
+		assertReceive(t, sut, expected)
+	})
+}
+
+func receiveEvent(t *testing.T, events <-chan *base.MessageEvent) *base.MessageEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(500 * time.Millisecond):
+		assert.FailNow(t, "expected listener to be called")
+		return nil
+	}
+}
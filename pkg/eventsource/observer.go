@@ -0,0 +1,86 @@
+package eventsource
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+var (
+	_ (Observer) = (*NopObserver)(nil)
+	_ (Observer) = (observers)(nil)
+)
+
+// Observer is notified about the lifecycle of an EventSource, see
+// WithObserver. Callbacks are invoked synchronously from the goroutine that
+// reads the stream, hence they must not block.
+type Observer interface {
+	// OnConnectAttempt is called before connecting to url.
+	OnConnectAttempt(url string)
+	// OnConnected is called once the server accepts the connection.
+	OnConnected(resp *http.Response)
+	// OnDisconnected is called when an open connection is lost.
+	OnDisconnected(err error)
+	// OnEvent is called for every event received, size is the amount of
+	// bytes of its id, name and data.
+	OnEvent(ev *base.MessageEvent, size int)
+	// OnRetryScheduled is called before waiting to reconnect.
+	OnRetryScheduled(delay time.Duration)
+	// OnClosed is called once the EventSource is closed.
+	OnClosed(err error)
+}
+
+// NopObserver does nothing, embed it to implement only some callbacks of
+// Observer.
+type NopObserver struct{}
+
+func (NopObserver) OnConnectAttempt(string)         {}
+func (NopObserver) OnConnected(*http.Response)      {}
+func (NopObserver) OnDisconnected(error)            {}
+func (NopObserver) OnEvent(*base.MessageEvent, int) {}
+func (NopObserver) OnRetryScheduled(time.Duration)  {}
+func (NopObserver) OnClosed(error)                  {}
+
+// observers notifies all of its observers.
+type observers []Observer
+
+func (os observers) OnConnectAttempt(url string) {
+	for _, o := range os {
+		o.OnConnectAttempt(url)
+	}
+}
+
+func (os observers) OnConnected(resp *http.Response) {
+	for _, o := range os {
+		o.OnConnected(resp)
+	}
+}
+
+func (os observers) OnDisconnected(err error) {
+	for _, o := range os {
+		o.OnDisconnected(err)
+	}
+}
+
+func (os observers) OnEvent(ev *base.MessageEvent, size int) {
+	for _, o := range os {
+		o.OnEvent(ev, size)
+	}
+}
+
+func (os observers) OnRetryScheduled(delay time.Duration) {
+	for _, o := range os {
+		o.OnRetryScheduled(delay)
+	}
+}
+
+func (os observers) OnClosed(err error) {
+	for _, o := range os {
+		o.OnClosed(err)
+	}
+}
+
+func eventSize(ev *base.MessageEvent) int {
+	return len(ev.ID) + len(ev.Name) + len(ev.Data)
+}
@@ -0,0 +1,83 @@
+package eventsource
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	sync.Mutex
+	calls []string
+}
+
+func (o *recordingObserver) record(format string, args ...interface{}) {
+	o.Lock()
+	defer o.Unlock()
+	o.calls = append(o.calls, fmt.Sprintf(format, args...))
+}
+
+func (o *recordingObserver) Calls() []string {
+	o.Lock()
+	defer o.Unlock()
+	return append([]string{}, o.calls...)
+}
+
+func (o *recordingObserver) OnConnectAttempt(string) { o.record("attempt") }
+func (o *recordingObserver) OnConnected(resp *http.Response) {
+	o.record("connected %d", resp.StatusCode)
+}
+func (o *recordingObserver) OnDisconnected(error) { o.record("disconnected") }
+func (o *recordingObserver) OnEvent(ev *base.MessageEvent, size int) {
+	o.record("event %s %d", ev.Data, size)
+}
+func (o *recordingObserver) OnRetryScheduled(delay time.Duration) { o.record("retry %s", delay) }
+func (o *recordingObserver) OnClosed(err error)                   { o.record("closed %v", err) }
+
+func TestEventSource_WithObserver(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		observer := &recordingObserver{}
+		sut, _ := New(handler.URL, WithObserver(observer), WithObserver(NopObserver{}), WithReadyStateChannel(128))
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{ID: "1", Data: "data"})
+		assertReceive(t, sut, &base.MessageEvent{ID: "1", Data: "data"})
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		<-handler.Connected
+		assertStates(t, []ReadyState{Connecting, Open, Connecting, Open}, sut)
+		sut.Close()
+
+		assert.Equal(t, []string{
+			"attempt",
+			"connected 200",
+			"event data 5",
+			"disconnected",
+			"retry 1ms",
+			"attempt",
+			"connected 200",
+			"disconnected",
+			"closed eventsource: closed",
+		}, observer.Calls())
+	})
+}
+
+func TestEventSource_WithObserver_WhenClosed_ThenReportsErrClosed(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		observer := &recordingObserver{}
+		sut, _ := New(handler.URL, WithObserver(observer))
+
+		<-handler.Connected
+		sut.Close()
+
+		calls := observer.Calls()
+		assert.Equal(t, "closed eventsource: closed", calls[len(calls)-1])
+	})
+}
@@ -0,0 +1,267 @@
+package eventsource
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/decoder"
+)
+
+// Option configures an EventSource, see New. RequestModifier is also an
+// Option.
+type Option interface {
+	apply(es *EventSource)
+}
+
+type optionFunc func(es *EventSource)
+
+func (fn optionFunc) apply(es *EventSource) {
+	fn(es)
+}
+
+// WithHTTPClient sets the HTTP client used to connect to the stream.
+// By default http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) Option {
+	return optionFunc(func(es *EventSource) {
+		es.client = client
+	})
+}
+
+// WithTransport sets the HTTP transport used to connect to the stream.
+// It takes precedence over the transport of the client set by WithHTTPClient.
+func WithTransport(transport http.RoundTripper) Option {
+	return optionFunc(func(es *EventSource) {
+		es.transport = transport
+	})
+}
+
+// WithBackoff sets the policy that decides how long to wait before
+// reconnecting. By default ConstantBackoff is used.
+func WithBackoff(policy BackoffPolicy) Option {
+	return optionFunc(func(es *EventSource) {
+		es.backoff = policy
+	})
+}
+
+// WithRetryBounds clamps the reconnection time advertised by the server
+// between min and max before it is handed to the BackoffPolicy. A zero
+// bound is ignored.
+func WithRetryBounds(min, max time.Duration) Option {
+	return optionFunc(func(es *EventSource) {
+		es.retryBounds.min = min
+		es.retryBounds.max = max
+	})
+}
+
+// WithMaxRetryAfter caps the delay the server requests with the Retry-After
+// header, by default 5 minutes. The max of WithRetryBounds also caps it. A
+// zero maximum removes the default cap.
+func WithMaxRetryAfter(max time.Duration) Option {
+	return optionFunc(func(es *EventSource) {
+		es.maxRetryAfter = max
+	})
+}
+
+// WithRetryableStatusCodes makes the EventSource reconnect when the server
+// responds with any of the given status codes. By default it reconnects on
+// 429, 500, 502, 503 and 504.
+func WithRetryableStatusCodes(codes ...int) Option {
+	return optionFunc(func(es *EventSource) {
+		for _, code := range codes {
+			es.retryableStatusCodes[code] = true
+		}
+	})
+}
+
+// WithFatalStatusCodes makes the EventSource close when the server responds
+// with any of the given status codes. Any status code that is not retryable
+// is fatal.
+func WithFatalStatusCodes(codes ...int) Option {
+	return optionFunc(func(es *EventSource) {
+		for _, code := range codes {
+			delete(es.retryableStatusCodes, code)
+		}
+	})
+}
+
+// WithMaxRedirects sets the maximum number of redirects followed when
+// connecting, by default 10.
+func WithMaxRedirects(max int) Option {
+	return optionFunc(func(es *EventSource) {
+		es.redirects.max = max
+	})
+}
+
+// WithCrossOriginRedirects allows or forbids redirects to a different
+// origin, allowed by default.
+func WithCrossOriginRedirects(allow bool) Option {
+	return optionFunc(func(es *EventSource) {
+		es.redirects.allowCrossOrigin = allow
+	})
+}
+
+// WithAuthorizationOnRedirect keeps the Authorization header, as set by
+// request modifiers, when redirected to a different host. By default it is
+// removed.
+func WithAuthorizationOnRedirect(keep bool) Option {
+	return optionFunc(func(es *EventSource) {
+		es.redirects.keepAuthorization = keep
+	})
+}
+
+// WithBufferSize sets how many events MessageEvents buffers before the
+// overflow strategy applies. By default it is not buffered.
+func WithBufferSize(size int) Option {
+	return optionFunc(func(es *EventSource) {
+		es.out = make(chan *base.MessageEvent, size)
+	})
+}
+
+// WithOverflowStrategy sets what happens to events that do not fit in the
+// buffer of MessageEvents, by default OverflowBlock. It only applies together
+// with WithBufferSize, unbuffered events always block.
+func WithOverflowStrategy(strategy OverflowStrategy) Option {
+	return optionFunc(func(es *EventSource) {
+		es.overflow.strategy = strategy
+	})
+}
+
+// WithOverflowHandler sets a function that is called with every event
+// discarded because events were not being consumed.
+func WithOverflowHandler(handler func(dropped *base.MessageEvent)) Option {
+	return optionFunc(func(es *EventSource) {
+		es.overflow.handler = handler
+	})
+}
+
+// WithLogger sets the logger used to report connection attempts, ready state
+// changes, reconnection delays and slow consumers. By default slog.Default
+// is used. Records are logged with debug level, except slow consumer
+// warnings.
+func WithLogger(logger *slog.Logger) Option {
+	return optionFunc(func(es *EventSource) {
+		es.logger = logger
+	})
+}
+
+// WithObserver adds an observer that is notified about the lifecycle of the
+// EventSource.
+func WithObserver(observer Observer) Option {
+	return optionFunc(func(es *EventSource) {
+		es.observers = append(es.observers, observer)
+	})
+}
+
+// WithDecoderOptions sets the options of the decoder of the stream, such as
+// decoder.WithMaxEventSize.
+func WithDecoderOptions(opts ...decoder.Option) Option {
+	return optionFunc(func(es *EventSource) {
+		es.decoderOptions = append(es.decoderOptions, opts...)
+	})
+}
+
+// WithStatusHandler sets a function that is called with every ready state
+// change. It is called synchronously, so it must not block nor call Close.
+// Calls never overlap and Closed is always the last state notified.
+func WithStatusHandler(handler func(status Status)) Option {
+	return optionFunc(func(es *EventSource) {
+		es.onStatus = handler
+	})
+}
+
+// WithReadyStateChannel enables the ReadyState channel, buffered with the
+// given size. The EventSource blocks when the buffer is full, so the channel
+// must be consumed.
+func WithReadyStateChannel(size int) Option {
+	return optionFunc(func(es *EventSource) {
+		es.readyState = make(chan Status, size)
+	})
+}
+
+// WithLastEventID sets the Last-Event-ID sent on the first connection.
+func WithLastEventID(id string) Option {
+	return optionFunc(func(es *EventSource) {
+		es.safe.lastEventID = id
+	})
+}
+
+// WithCheckpointStore restores the last event ID from the store when
+// connecting for the first time, and saves it as events are delivered. A
+// checkpoint found in the store takes precedence over WithLastEventID.
+func WithCheckpointStore(store CheckpointStore) Option {
+	return optionFunc(func(es *EventSource) {
+		es.checkpoint.store = store
+	})
+}
+
+// WithManualAck disables saving checkpoints as events are delivered, instead
+// the application saves them with Ack after handling the events.
+func WithManualAck() Option {
+	return optionFunc(func(es *EventSource) {
+		es.checkpoint.manualAck = true
+	})
+}
+
+// BodyFunc returns the body of the request sent on every connection attempt,
+// it receives the last event ID so the body can resume the stream.
+type BodyFunc func(lastEventID string) (io.Reader, error)
+
+// WithMethod sets the HTTP method of the request, by default GET.
+func WithMethod(method string) Option {
+	return optionFunc(func(es *EventSource) {
+		es.method = method
+	})
+}
+
+// WithBody sets a function that provides the body of the request, it is
+// invoked on every connection attempt. Bodies of type *bytes.Buffer,
+// *bytes.Reader or *strings.Reader can be replayed when redirected.
+func WithBody(body BodyFunc) Option {
+	return optionFunc(func(es *EventSource) {
+		es.body = body
+	})
+}
+
+// WithIdleTimeout considers the connection dead when no bytes, neither events
+// nor comments, are received during the timeout. The connection is then
+// closed and the EventSource reconnects, reporting ErrIdleTimeout.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return optionFunc(func(es *EventSource) {
+		es.idleTimeout = timeout
+	})
+}
+
+// WithEndpointResolver sets the resolver that chooses the URL of every
+// connection attempt, the URL given to New is then ignored. When connecting
+// fails, the EventSource keeps reconnecting for as long as the error is
+// retryable. On the first connection every endpoint is tried at most once,
+// New returns the last error when none of them connects.
+func WithEndpointResolver(resolver EndpointResolver) Option {
+	return optionFunc(func(es *EventSource) {
+		es.resolver = resolver
+	})
+}
+
+// WithFailover fails over to the fallback URLs, in order, when connecting to
+// the URL given to New fails, see Failover and WithEndpointResolver. Once the
+// cool-down elapses, a connection to a fallback is replaced by one to the
+// URL given to New, when it can be established.
+func WithFailover(cooldown time.Duration, fallbacks ...string) Option {
+	return optionFunc(func(es *EventSource) {
+		es.resolver = NewFailover(cooldown, append([]string{es.URL()}, fallbacks...)...)
+	})
+}
+
+// WithMaxConnectionLifetime replaces connections once they are open for the
+// given duration. The replacement connection is established, resuming from
+// the last event ID, before the current one is closed. Events delivered by
+// both connections are discarded from the replacement, as long as they
+// have an ID.
+func WithMaxConnectionLifetime(lifetime time.Duration) Option {
+	return optionFunc(func(es *EventSource) {
+		es.rotation.lifetime = lifetime
+	})
+}
@@ -0,0 +1,182 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/decoder"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTransport struct {
+	requests int
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		transport := &countingTransport{}
+		client := &http.Client{Transport: transport}
+
+		sut, err := New(handler.URL, WithHTTPClient(client))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+		assert.Equal(t, 1, transport.requests)
+	})
+}
+
+func TestWithTransport(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		transport := &countingTransport{}
+
+		sut, err := New(handler.URL, WithTransport(transport))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+		assert.Equal(t, 1, transport.requests)
+		assert.Nil(t, http.DefaultClient.Transport, "default client must not be modified")
+	})
+}
+
+func TestWithTransport_TakesPrecedenceOverHTTPClient(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		transport := &countingTransport{}
+		clientTransport := &countingTransport{}
+
+		sut, err := New(handler.URL, WithTransport(transport), WithHTTPClient(&http.Client{Transport: clientTransport}))
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+		assert.Equal(t, 1, transport.requests)
+		assert.Equal(t, 0, clientTransport.requests)
+	})
+}
+
+type recordingHandler struct {
+	sync.Mutex
+	messages []string
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.Lock()
+	defer h.Unlock()
+	h.messages = append(h.messages, record.Message)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) Messages() []string {
+	h.Lock()
+	defer h.Unlock()
+	return append([]string{}, h.messages...)
+}
+
+func TestWithDecoderOptions_RecordHandler(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		comments := make(chan decoder.Record, 1)
+		sut, _ := New(handler.URL, WithDecoderOptions(decoder.WithRecordHandler(func(r decoder.Record) {
+			if _, ok := r.(decoder.Comment); ok {
+				comments <- r
+			}
+		})))
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Data: "data"})
+
+		assertReceive(t, sut, &base.MessageEvent{Data: "data"})
+		assert.Equal(t, decoder.Comment{Text: "sending test event"}, <-comments)
+	})
+}
+
+func TestWithLogger(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		recorder := &recordingHandler{}
+		sut, _ := New(handler.URL, WithLogger(slog.New(recorder)))
+
+		<-handler.Connected
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		<-handler.Connected
+		sut.Close()
+
+		assert.Equal(t, []string{
+			"eventsource: ready state changed",
+			"eventsource: connecting",
+			"eventsource: ready state changed",
+			"eventsource: retry updated",
+			"eventsource: reconnecting",
+			"eventsource: ready state changed",
+			"eventsource: connecting",
+		}, recorder.Messages()[:7])
+	})
+}
+
+func TestEventSource_WithMethodAndBody(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		requests := make(chan string, 2)
+		handler.OnRequest = func(req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			requests <- req.Method + " " + string(body)
+		}
+
+		sut, _ := New(
+			handler.URL,
+			WithMethod(http.MethodPost),
+			WithBody(func(lastEventID string) (io.Reader, error) {
+				return strings.NewReader(`{"after":"` + lastEventID + `"}`), nil
+			}),
+		)
+		defer sut.Close()
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{ID: "event-id"})
+		assertReceive(t, sut, &base.MessageEvent{ID: "event-id"})
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		<-handler.Connected
+
+		assert.Equal(t, `POST {"after":""}`, <-requests)
+		assert.Equal(t, `POST {"after":"event-id"}`, <-requests)
+	})
+}
+
+func TestEventSource_WhenBodyFails_ThenReturnsError(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, err := New(handler.URL, WithBody(func(string) (io.Reader, error) {
+			return nil, errors.New("cannot build body")
+		}))
+		defer sut.Close()
+
+		assert.EqualError(t, err, "cannot build body")
+	})
+}
@@ -0,0 +1,116 @@
+package eventsource
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+//go:generate stringer -type=OverflowStrategy
+
+// OverflowStrategy decides what happens to an event when the buffer of
+// MessageEvents is full because events are not being consumed. Without a
+// buffer, see WithBufferSize, events are always delivered with OverflowBlock.
+type OverflowStrategy uint8
+
+const (
+	// OverflowBlock waits until the event can be delivered, which stops
+	// reading the stream.
+	OverflowBlock OverflowStrategy = iota
+	// OverflowDropOldest discards the oldest buffered event to make room.
+	OverflowDropOldest
+	// OverflowDropNewest discards the event that does not fit.
+	OverflowDropNewest
+	// OverflowClose discards the event and closes the EventSource with
+	// ErrSlowConsumer.
+	OverflowClose
+)
+
+// ErrSlowConsumer means the EventSource was closed because events were not
+// being consumed, see OverflowClose.
+var ErrSlowConsumer = fatalKind("eventsource: events are not being consumed")
+
+type overflow struct {
+	strategy OverflowStrategy
+	handler  func(dropped *base.MessageEvent)
+	dropped  uint64
+}
+
+// Dropped returns the number of events discarded because events were
+// not being consumed.
+func (es *EventSource) Dropped() uint64 {
+	return atomic.LoadUint64(&es.overflow.dropped)
+}
+
+// send delivers the event to MessageEvents applying the overflow strategy,
+// it returns false when the EventSource must stop.
+func (es *EventSource) send(ev *base.MessageEvent) bool {
+	if cap(es.out) == 0 {
+		// An unbuffered channel is only ready when the consumer is parked
+		// on it, which says nothing about whether it keeps up
+		return es.sendBlocking(ev)
+	}
+
+	select {
+	case es.out <- ev:
+		return true
+	default:
+	}
+
+	switch es.overflow.strategy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case es.out <- ev:
+				return true
+			default:
+			}
+			select {
+			case oldest := <-es.out:
+				es.drop(oldest)
+			default:
+				// Nothing is buffered, the event cannot be delivered
+				es.drop(ev)
+				return true
+			}
+		}
+	case OverflowDropNewest:
+		es.drop(ev)
+		return true
+	case OverflowClose:
+		es.drop(ev)
+		es.doClose(ErrSlowConsumer)
+		return false
+	default:
+		return es.sendBlocking(ev)
+	}
+}
+
+// sendBlocking waits until the event is delivered, warning about the slow
+// consumer every second.
+func (es *EventSource) sendBlocking(ev *base.MessageEvent) bool {
+	for {
+		select {
+		case <-es.ctx.Done():
+			es.doClose(es.ctx.Err())
+			return false
+		case es.out <- ev:
+			return true
+		case <-time.After(1 * time.Second):
+			es.logger.Warn("eventsource: slow consumer, messages are not being consumed")
+		}
+	}
+}
+
+func (es *EventSource) drop(ev *base.MessageEvent) {
+	dropped := atomic.AddUint64(&es.overflow.dropped, 1)
+	es.logger.Debug("eventsource: event dropped, messages are not being consumed",
+		slog.String("strategy", es.overflow.strategy.String()),
+		slog.Uint64("dropped", dropped),
+	)
+	if es.overflow.handler != nil {
+		es.overflow.handler(ev)
+	}
+}
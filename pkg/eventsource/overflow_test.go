@@ -0,0 +1,89 @@
+package eventsource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils"
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSource_WithOverflowDropNewest(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithBufferSize(1), WithOverflowStrategy(OverflowDropNewest))
+		defer sut.Close()
+
+		<-handler.Connected
+		writeEvents(handler, "first", "second", "third")
+		testutils.ExpectCondition(t, func() bool { return sut.Dropped() == 2 })
+
+		assertReceive(t, sut, &base.MessageEvent{Data: "first"})
+	})
+}
+
+func TestEventSource_WithOverflowDropOldest(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		dropped := make(chan *base.MessageEvent, 2)
+		sut, _ := New(
+			handler.URL,
+			WithBufferSize(1),
+			WithOverflowStrategy(OverflowDropOldest),
+			WithOverflowHandler(func(ev *base.MessageEvent) { dropped <- ev }),
+		)
+		defer sut.Close()
+
+		<-handler.Connected
+		writeEvents(handler, "first", "second", "third")
+		testutils.ExpectCondition(t, func() bool { return sut.Dropped() == 2 })
+
+		assertReceive(t, sut, &base.MessageEvent{Data: "third"})
+		assert.Equal(t, "first", (<-dropped).Data)
+		assert.Equal(t, "second", (<-dropped).Data)
+	})
+}
+
+func TestEventSource_WithOverflowClose(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithBufferSize(1), WithOverflowStrategy(OverflowClose), WithReadyStateChannel(128))
+		defer sut.Close()
+
+		<-handler.Connected
+		writeEvents(handler, "first", "second")
+		testutils.ExpectCondition(t, func() bool { return sut.Dropped() == 1 })
+
+		assertReceive(t, sut, &base.MessageEvent{Data: "first"})
+		assertNoReceives(t, sut)
+		<-sut.ReadyState()
+		<-sut.ReadyState()
+		assert.Equal(t, Status{ReadyState: Closed, Err: ErrSlowConsumer}, <-sut.ReadyState())
+	})
+}
+
+func TestEventSource_WithOverflowDropNewest_WhenUnbuffered_ThenBlocks(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		sut, _ := New(handler.URL, WithOverflowStrategy(OverflowDropNewest))
+		defer sut.Close()
+
+		<-handler.Connected
+		go writeEvents(handler, "first", "second", "third")
+		time.Sleep(10 * time.Millisecond)
+
+		assertReceive(t, sut, &base.MessageEvent{Data: "first"})
+		assertReceive(t, sut, &base.MessageEvent{Data: "second"})
+		assertReceive(t, sut, &base.MessageEvent{Data: "third"})
+		assert.Zero(t, sut.Dropped())
+	})
+}
+
+func TestOverflowStrategy_String(t *testing.T) {
+	assert.Equal(t, "OverflowDropOldest", OverflowDropOldest.String())
+	assert.Equal(t, "OverflowStrategy(9)", OverflowStrategy(9).String())
+}
+
+func writeEvents(handler *server.MockHandler, data ...string) {
+	for _, d := range data {
+		handler.WriteEvent(&base.MessageEvent{Data: d})
+	}
+}
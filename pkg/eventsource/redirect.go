@@ -0,0 +1,62 @@
+package eventsource
+
+import "net/http"
+
+// Maximum number of redirects followed by default, same as http.Client.
+const defaultMaxRedirects = 10
+
+var (
+	// ErrTooManyRedirects means the server redirected the request more times
+	// than allowed, see WithMaxRedirects.
+	ErrTooManyRedirects = fatalKind("eventsource: too many redirects")
+
+	// ErrCrossOriginRedirect means the server redirected the request to a
+	// different origin while not allowed, see WithCrossOriginRedirects.
+	ErrCrossOriginRedirect = fatalKind("eventsource: cross-origin redirect is not allowed")
+)
+
+type redirectPolicy struct {
+	max               int
+	allowCrossOrigin  bool
+	keepAuthorization bool
+}
+
+// withRedirectPolicy returns a copy of client that enforces the redirect
+// policy before delegating to the CheckRedirect of the client, if any.
+func withRedirectPolicy(client *http.Client, policy redirectPolicy) *http.Client {
+	checkRedirect := client.CheckRedirect
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := policy.check(req, via); err != nil {
+			return err
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		return nil
+	}
+	return &c
+}
+
+func (p redirectPolicy) check(req *http.Request, via []*http.Request) error {
+	if len(via) > p.max {
+		return ErrTooManyRedirects
+	}
+
+	initial := via[0]
+	sameHost := req.URL.Host == initial.URL.Host
+	if !p.allowCrossOrigin && (!sameHost || req.URL.Scheme != initial.URL.Scheme) {
+		return ErrCrossOriginRedirect
+	}
+
+	// http.Client drops the Authorization header when redirecting to a
+	// different domain, but keeps it for subdomains.
+	if p.keepAuthorization {
+		if auth := initial.Header.Get("Authorization"); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	} else if !sameHost {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package eventsource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSource_WhenRedirected_ThenURLIsUpdated(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.MaxRequestsToProcess = 2
+		redirector, requests := newRedirector(handler.URL, http.StatusTemporaryRedirect)
+		defer redirector.Close()
+
+		sut, err := New(redirector.URL)
+		assert.NoError(t, err)
+		defer sut.Close()
+
+		<-handler.Connected
+		assert.Equal(t, handler.URL, sut.URL())
+
+		handler.WriteRetry(1, sut.getDecoder)
+		handler.CloseActiveRequest(true)
+		<-handler.Connected
+
+		assert.Equal(t, int32(1), requests.Load(), "reconnection must use the redirected url")
+	})
+}
+
+func TestEventSource_WithMaxRedirects(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		redirector, _ := newRedirector(handler.URL, http.StatusMovedPermanently)
+		defer redirector.Close()
+
+		sut, err := New(redirector.URL, WithMaxRedirects(0))
+		defer sut.Close()
+
+		assert.ErrorIs(t, err, ErrTooManyRedirects)
+		assert.Equal(t, redirector.URL, sut.URL())
+	})
+}
+
+func TestEventSource_WithCrossOriginRedirects(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		redirector, _ := newRedirector(handler.URL, http.StatusTemporaryRedirect)
+		defer redirector.Close()
+
+		sut, err := New(redirector.URL, WithCrossOriginRedirects(false))
+		defer sut.Close()
+
+		assert.ErrorIs(t, err, ErrCrossOriginRedirect)
+	})
+}
+
+func TestEventSource_WhenRedirectedToOtherHost_ThenAuthorizationIsRemoved(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.BasicAuth.Username = "foo"
+		handler.BasicAuth.Password = "bar"
+		redirector, _ := newRedirector(handler.URL, http.StatusTemporaryRedirect)
+		defer redirector.Close()
+
+		sut, err := New(redirector.URL, WithBasicAuth("foo", "bar"))
+		defer sut.Close()
+
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+}
+
+func TestEventSource_WithAuthorizationOnRedirect(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		handler.BasicAuth.Username = "foo"
+		handler.BasicAuth.Password = "bar"
+		redirector, _ := newRedirector(handler.URL, http.StatusTemporaryRedirect)
+		defer redirector.Close()
+
+		sut, err := New(redirector.URL, WithBasicAuth("foo", "bar"), WithAuthorizationOnRedirect(true))
+		defer sut.Close()
+
+		assert.NoError(t, err)
+		<-handler.Connected
+	})
+}
+
+func TestWithRedirectPolicy_DelegatesToClient(t *testing.T) {
+	var called bool
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		called = true
+		return http.ErrUseLastResponse
+	}}
+
+	sut := withRedirectPolicy(client, redirectPolicy{max: 1})
+	req := httptest.NewRequest(http.MethodGet, "http://host/b", nil)
+	via := []*http.Request{httptest.NewRequest(http.MethodGet, "http://host/a", nil)}
+
+	assert.Equal(t, http.ErrUseLastResponse, sut.CheckRedirect(req, via))
+	assert.True(t, called)
+}
+
+func newRedirector(target string, code int) (*httptest.Server, *atomic.Int32) {
+	requests := new(atomic.Int32)
+	redirector := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		http.Redirect(rw, req, target, code)
+	}))
+	return redirector, requests
+}
@@ -5,6 +5,10 @@ import "net/http"
 // RequestModifier function for modifying the HTTP connection request.
 type RequestModifier func(r *http.Request)
 
+func (rm RequestModifier) apply(es *EventSource) {
+	es.requestModifiers = append(es.requestModifiers, rm)
+}
+
 // WithBasicAuth adds basic authentication to the HTTP request
 func WithBasicAuth(username, password string) RequestModifier {
 	return func(r *http.Request) {
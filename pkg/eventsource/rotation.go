@@ -0,0 +1,157 @@
+package eventsource
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+// rotation replaces connections that reach their maximum lifetime, see
+// WithMaxConnectionLifetime, and connections to a fallback once the
+// Failover cool-down elapses.
+type rotation struct {
+	lifetime time.Duration
+	next     chan handoff
+
+	mu         sync.Mutex
+	timer      *time.Timer
+	generation uint64
+	stopped    bool
+	running    bool
+}
+
+// handoff carries the replacement connection, resp is nil when it could not
+// be established.
+type handoff struct {
+	endpoint string
+	resp     *http.Response
+}
+
+// scheduleRotation replaces the connection once it reaches its lifetime, or
+// once it is time to fail back to the primary endpoint.
+func (es *EventSource) scheduleRotation(current *http.Response) {
+	es.rotation.mu.Lock()
+	defer es.rotation.mu.Unlock()
+	es.rotation.generation++
+	es.rotation.stopped = false
+	es.rotation.running = false
+	es.rotation.timer = es.afterRotationDelay(es.rotation.generation, current)
+}
+
+// afterRotationDelay schedules the rotation, it returns nil when the
+// connection is never replaced.
+func (es *EventSource) afterRotationDelay(generation uint64, current *http.Response) *time.Timer {
+	delay, ok := es.rotation.lifetime, es.rotation.lifetime > 0
+	if f, isFailover := es.resolver.(*Failover); isFailover {
+		if failback, pending := f.failbackIn(); pending && (!ok || failback < delay) {
+			delay, ok = failback, true
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return time.AfterFunc(delay, func() {
+		es.rotate(generation, current)
+	})
+}
+
+// stopRotation cancels the rotation of the current connection. When the
+// rotation is already in progress it waits for it, and returns the
+// replacement connection if it was established.
+func (es *EventSource) stopRotation() (handoff, bool) {
+	es.rotation.mu.Lock()
+	es.rotation.stopped = true
+	if es.rotation.timer != nil {
+		es.rotation.timer.Stop()
+	}
+	running := es.rotation.running
+	es.rotation.mu.Unlock()
+
+	if !running {
+		return handoff{}, false
+	}
+	h := <-es.rotation.next
+	return h, h.resp != nil
+}
+
+// rotate opens the replacement connection before closing the current one,
+// so no events are missed. Events received by the current connection in
+// the meantime are recorded, so the ones the replacement connection
+// delivers again are discarded.
+func (es *EventSource) rotate(generation uint64, current *http.Response) {
+	es.rotation.mu.Lock()
+	if es.rotation.stopped || es.rotation.generation != generation {
+		es.rotation.mu.Unlock()
+		return
+	}
+	es.rotation.running = true
+	es.rotation.mu.Unlock()
+
+	es.safe.Lock()
+	es.safe.seen = map[string]struct{}{}
+	lastEventID := es.safe.lastEventID
+	es.safe.Unlock()
+
+	endpoint, url, err := es.resolve()
+	var resp *http.Response
+	if err == nil {
+		resp, err = es.doHTTPConnect(url, lastEventID)
+		if es.resolver != nil {
+			es.resolver.Report(endpoint, err)
+		}
+	}
+
+	if err != nil {
+		es.logger.Debug("eventsource: cannot rotate connection", slog.Any("err", err))
+		es.safe.Lock()
+		es.safe.seen = nil
+		es.safe.Unlock()
+
+		es.rotation.mu.Lock()
+		defer es.rotation.mu.Unlock()
+		if es.rotation.stopped {
+			es.rotation.next <- handoff{}
+			return
+		}
+		// stopRotation must not wait for this rotation, even when the
+		// EventSource is closing
+		es.rotation.running = false
+		if es.ctx.Err() == nil {
+			es.rotation.timer = es.afterRotationDelay(generation, current)
+		}
+		return
+	}
+
+	if es.idleTimeout > 0 {
+		resp.Body = newIdleReader(resp.Body, es.idleTimeout)
+	}
+	es.rotation.next <- handoff{endpoint: endpoint, resp: resp}
+	current.Body.Close()
+}
+
+// track updates the last event ID, it returns false for events that were
+// already delivered by the connection that was rotated. Events without ID
+// cannot be told apart, hence they are never discarded.
+func (es *EventSource) track(ev *base.MessageEvent) bool {
+	if !ev.HasID {
+		return true
+	}
+
+	es.safe.Lock()
+	defer es.safe.Unlock()
+
+	if es.safe.dedup != nil {
+		if _, seen := es.safe.dedup[ev.ID]; seen {
+			return false
+		}
+		es.safe.dedup = nil
+	}
+	if es.safe.seen != nil {
+		es.safe.seen[ev.ID] = struct{}{}
+	}
+	es.safe.lastEventID = ev.ID
+	return true
+}
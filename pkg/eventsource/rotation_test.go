@@ -0,0 +1,115 @@
+package eventsource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/encoder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSource_WithMaxConnectionLifetime_ThenNoGapsNorDuplicates(t *testing.T) {
+	server, requests := newCountingServer()
+	defer server.Close()
+
+	sut, err := New(server.URL, WithMaxConnectionLifetime(40*time.Millisecond), WithReadyStateChannel(128))
+	assert.NoError(t, err)
+	defer sut.Close()
+
+	deadline := time.After(200 * time.Millisecond)
+	expected := 1
+	for done := false; !done; {
+		select {
+		case ev := <-sut.MessageEvents():
+			assert.Equal(t, strconv.Itoa(expected), ev.ID)
+			expected++
+		case <-deadline:
+			done = true
+		}
+	}
+
+	assert.GreaterOrEqual(t, requests.Load(), int32(3))
+	assertStates(t, []ReadyState{Connecting, Open}, sut)
+}
+
+func TestEventSource_WithMaxConnectionLifetime_WhenClosedWhileRotating_ThenDoesNotBlock(t *testing.T) {
+	requests := new(atomic.Int32)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if requests.Add(1) > 1 {
+			<-req.Context().Done()
+			return
+		}
+		rw.Header().Set("Content-Type", ContentType)
+		rw.(http.Flusher).Flush()
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	sut, err := New(server.URL, WithMaxConnectionLifetime(5*time.Millisecond))
+	assert.NoError(t, err)
+	time.Sleep(15 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		sut.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked while the connection was being rotated")
+	}
+}
+
+func TestEventSource_Track_DiscardsDuplicatesAfterRotation(t *testing.T) {
+	sut := &EventSource{}
+	sut.safe.seen = map[string]struct{}{}
+
+	assert.True(t, sut.track(&base.MessageEvent{ID: "1", HasID: true}))
+	assert.True(t, sut.track(&base.MessageEvent{ID: "2", HasID: true}))
+
+	sut.safe.dedup, sut.safe.seen = sut.safe.seen, nil
+
+	assert.False(t, sut.track(&base.MessageEvent{ID: "1", HasID: true}))
+	assert.True(t, sut.track(&base.MessageEvent{Data: "no id"}))
+	assert.False(t, sut.track(&base.MessageEvent{ID: "2", HasID: true}))
+	assert.True(t, sut.track(&base.MessageEvent{ID: "3", HasID: true}))
+	assert.True(t, sut.track(&base.MessageEvent{ID: "1", HasID: true}), "discards only until a new event")
+	assert.Equal(t, "1", sut.getLastEventID())
+}
+
+// newCountingServer streams events with consecutive IDs, resuming after the
+// Last-Event-ID. Resumed connections are slow to respond, so the connection
+// being replaced keeps delivering events that are sent again.
+func newCountingServer() (*httptest.Server, *atomic.Int32) {
+	requests := new(atomic.Int32)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		id, _ := strconv.Atoi(req.Header.Get("Last-Event-ID"))
+		if id > 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		rw.Header().Set("Content-Type", ContentType)
+		e := encoder.New(rw)
+		flusher := rw.(http.Flusher)
+		flusher.Flush()
+		for {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-time.After(2 * time.Millisecond):
+			}
+			id++
+			e.WriteEvent(&base.MessageEvent{ID: strconv.Itoa(id), Data: "data"})
+			flusher.Flush()
+		}
+	}))
+	return server, requests
+}
@@ -0,0 +1,162 @@
+package eventsource
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alevinval/sse/pkg/base"
+)
+
+var _ (Codec) = (*JSONCodec)(nil)
+
+// Codec decodes the data of events into values.
+type Codec interface {
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec decodes the data of events as JSON.
+type JSONCodec struct{}
+
+// Unmarshal decodes the JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// TypedEvent is an event with its data decoded into a value.
+type TypedEvent[T any] struct {
+	ID    string
+	Name  string
+	Value T
+}
+
+// DecodeError means the data of an event could not be decoded.
+type DecodeError struct {
+	Event *base.MessageEvent
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("eventsource: cannot decode event %q: %s", e.Event.ID, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// TypedOption configures a Typed event source, see NewTyped.
+type TypedOption func(c *typedConfig)
+
+type typedConfig struct {
+	codec   Codec
+	onError func(err error)
+	types   map[string]func() any
+}
+
+// WithCodec sets the codec that decodes the data of events, by default
+// JSONCodec.
+func WithCodec(codec Codec) TypedOption {
+	return func(c *typedConfig) {
+		c.codec = codec
+	}
+}
+
+// WithDecodeErrorHandler sets a function that is called with every
+// DecodeError, instead of sending them to Errors.
+func WithDecodeErrorHandler(fn func(err error)) TypedOption {
+	return func(c *typedConfig) {
+		c.onError = fn
+	}
+}
+
+// WithEventType decodes the data of events with the given name into the
+// value returned by newValue, usually a pointer to a concrete type that
+// implements the type parameter of Typed.
+func WithEventType(name string, newValue func() any) TypedOption {
+	return func(c *typedConfig) {
+		c.types[name] = newValue
+	}
+}
+
+// Typed decodes the data of the events of an EventSource into values of
+// type T.
+type Typed[T any] struct {
+	es     *EventSource
+	config typedConfig
+	events chan TypedEvent[T]
+	errors chan error
+}
+
+// NewTyped starts decoding the events received from MessageEvents, hence
+// the EventSource events must not be consumed elsewhere.
+func NewTyped[T any](es *EventSource, opts ...TypedOption) *Typed[T] {
+	t := &Typed[T]{
+		es:     es,
+		config: typedConfig{codec: JSONCodec{}, types: map[string]func() any{}},
+		events: make(chan TypedEvent[T]),
+		errors: make(chan error),
+	}
+	for _, opt := range opts {
+		opt(&t.config)
+	}
+
+	go t.decodeAll()
+	return t
+}
+
+// Events returns a receive-only channel where decoded events are received.
+// It is closed once the EventSource is closed.
+func (t *Typed[T]) Events() <-chan TypedEvent[T] {
+	return t.events
+}
+
+// Errors exposes a channel with the events that could not be decoded, as
+// DecodeError. Unless WithDecodeErrorHandler is used, it must be consumed
+// together with Events.
+func (t *Typed[T]) Errors() <-chan error {
+	return t.errors
+}
+
+func (t *Typed[T]) decodeAll() {
+	defer close(t.events)
+	defer close(t.errors)
+
+	for ev := range t.es.MessageEvents() {
+		value, err := t.decode(ev)
+		if err != nil {
+			err = &DecodeError{Event: ev, Err: err}
+			if t.config.onError != nil {
+				t.config.onError(err)
+				continue
+			}
+			select {
+			case t.errors <- err:
+			case <-t.es.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		select {
+		case t.events <- TypedEvent[T]{ID: ev.ID, Name: ev.Name, Value: value}:
+		case <-t.es.ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *Typed[T]) decode(ev *base.MessageEvent) (value T, err error) {
+	newValue, ok := t.config.types[ev.Name]
+	if !ok {
+		err = t.config.codec.Unmarshal([]byte(ev.Data), &value)
+		return
+	}
+
+	v := newValue()
+	if err = t.config.codec.Unmarshal([]byte(ev.Data), v); err != nil {
+		return
+	}
+	if value, ok = v.(T); !ok {
+		err = fmt.Errorf("type %T registered for event %q is not %T", v, ev.Name, value)
+	}
+	return
+}
@@ -0,0 +1,155 @@
+package eventsource
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/internal/testutils/server"
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+type quote struct {
+	Symbol string  `json:"symbol"`
+	Price  float64 `json:"price"`
+}
+
+type update interface {
+	kind() string
+}
+
+type trade struct {
+	Size int `json:"size"`
+}
+
+func (*trade) kind() string { return "trade" }
+
+type halt struct {
+	Reason string `json:"reason"`
+}
+
+func (*halt) kind() string { return "halt" }
+
+type upperCodec struct{}
+
+func (upperCodec) Unmarshal(data []byte, v any) error {
+	*v.(*string) = string(data) + "!"
+	return nil
+}
+
+func TestTyped_DecodesJSON(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		es, _ := New(handler.URL)
+		defer es.Close()
+		sut := NewTyped[quote](es)
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{ID: "1", Name: "quote", Data: `{"symbol":"ACME","price":1.5}`})
+
+		ev := receiveTyped(t, sut.Events())
+		assert.Equal(t, "1", ev.ID)
+		assert.Equal(t, "quote", ev.Name)
+		assert.Equal(t, quote{Symbol: "ACME", Price: 1.5}, ev.Value)
+	})
+}
+
+func TestTyped_WithCodec(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		es, _ := New(handler.URL)
+		defer es.Close()
+		sut := NewTyped[string](es, WithCodec(upperCodec{}))
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Data: "hello"})
+
+		assert.Equal(t, "hello!", receiveTyped(t, sut.Events()).Value)
+	})
+}
+
+func TestTyped_DecodeErrorsAreSentToErrors(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		es, _ := New(handler.URL)
+		defer es.Close()
+		sut := NewTyped[quote](es)
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{ID: "1", Data: "not json"})
+		handler.WriteEvent(&base.MessageEvent{ID: "2", Data: `{"symbol":"ACME"}`})
+
+		select {
+		case err := <-sut.Errors():
+			var decodeErr *DecodeError
+			if assert.True(t, errors.As(err, &decodeErr)) {
+				assert.Equal(t, "1", decodeErr.Event.ID)
+			}
+		case <-time.After(500 * time.Millisecond):
+			assert.FailNow(t, "expected a decode error")
+		}
+		assert.Equal(t, "2", receiveTyped(t, sut.Events()).ID)
+	})
+}
+
+func TestTyped_WithDecodeErrorHandler(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		es, _ := New(handler.URL)
+		defer es.Close()
+		failed := make(chan error, 1)
+		sut := NewTyped[quote](es, WithDecodeErrorHandler(func(err error) { failed <- err }))
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Data: "not json"})
+		handler.WriteEvent(&base.MessageEvent{ID: "2", Data: `{}`})
+
+		assert.Equal(t, "2", receiveTyped(t, sut.Events()).ID)
+		assert.Error(t, <-failed)
+	})
+}
+
+func TestTyped_WithEventType(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		es, _ := New(handler.URL)
+		defer es.Close()
+		sut := NewTyped[update](es,
+			WithEventType("trade", func() any { return &trade{} }),
+			WithEventType("halt", func() any { return &halt{} }),
+			WithEventType("bogus", func() any { return &quote{} }),
+		)
+
+		<-handler.Connected
+		handler.WriteEvent(&base.MessageEvent{Name: "trade", Data: `{"size":10}`})
+		handler.WriteEvent(&base.MessageEvent{Name: "halt", Data: `{"reason":"news"}`})
+
+		assert.Equal(t, &trade{Size: 10}, receiveTyped(t, sut.Events()).Value)
+		assert.Equal(t, &halt{Reason: "news"}, receiveTyped(t, sut.Events()).Value)
+
+		handler.WriteEvent(&base.MessageEvent{Name: "bogus", Data: `{}`})
+		assert.Error(t, <-sut.Errors())
+	})
+}
+
+func TestTyped_ChannelsCloseWithEventSource(t *testing.T) {
+	setUp(t, func(handler *server.MockHandler) {
+		es, _ := New(handler.URL)
+		sut := NewTyped[quote](es)
+
+		<-handler.Connected
+		es.Close()
+
+		_, ok := <-sut.Events()
+		assert.False(t, ok)
+		_, ok = <-sut.Errors()
+		assert.False(t, ok)
+	})
+}
+
+func receiveTyped[T any](t *testing.T, events <-chan TypedEvent[T]) TypedEvent[T] {
+	select {
+	case ev, ok := <-events:
+		assert.True(t, ok, "expected to receive an event")
+		return ev
+	case <-time.After(500 * time.Millisecond):
+		assert.FailNow(t, "expected to receive an event")
+		return TypedEvent[T]{}
+	}
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/alevinval/sse/pkg/eventsource"
+)
+
+var (
+	_ (eventsource.Observer) = (*Collector)(nil)
+	_ (http.Handler)         = (*Collector)(nil)
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	eventSizeBuckets  = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+	retryDelayBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+)
+
+// Collector aggregates the lifecycle of one or many event sources, see
+// eventsource.WithObserver, and serves them as Prometheus metrics.
+type Collector struct {
+	mu             sync.Mutex
+	connectAttempt uint64
+	connected      uint64
+	disconnected   uint64
+	closed         uint64
+	open           int64
+	events         uint64
+	eventSize      *histogram
+	retryDelay     *histogram
+}
+
+// NewCollector returns a Collector with all metrics set to zero.
+func NewCollector() *Collector {
+	return &Collector{
+		eventSize:  newHistogram(eventSizeBuckets),
+		retryDelay: newHistogram(retryDelayBuckets),
+	}
+}
+
+// OnConnectAttempt counts connection attempts.
+func (c *Collector) OnConnectAttempt(string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connectAttempt++
+}
+
+// OnConnected counts established connections.
+func (c *Collector) OnConnected(*http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected++
+	c.open++
+}
+
+// OnDisconnected counts lost connections.
+func (c *Collector) OnDisconnected(error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disconnected++
+	c.open--
+}
+
+// OnEvent counts events and observes their size.
+func (c *Collector) OnEvent(_ *base.MessageEvent, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events++
+	c.eventSize.observe(float64(size))
+}
+
+// OnRetryScheduled observes reconnection delays.
+func (c *Collector) OnRetryScheduled(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryDelay.observe(delay.Seconds())
+}
+
+// OnClosed counts closed event sources.
+func (c *Collector) OnClosed(error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", contentType)
+	c.WriteTo(rw)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := &writer{w: w}
+	out.counter("eventsource_connect_attempts_total", "Number of connection attempts.", c.connectAttempt)
+	out.counter("eventsource_connections_total", "Number of connections established.", c.connected)
+	out.counter("eventsource_disconnections_total", "Number of connections lost.", c.disconnected)
+	out.gauge("eventsource_open_connections", "Number of connections currently open.", c.open)
+	out.counter("eventsource_closed_total", "Number of event sources closed.", c.closed)
+	out.counter("eventsource_events_total", "Number of events received.", c.events)
+	out.histogram("eventsource_event_size_bytes", "Size of the id, name and data of received events.", c.eventSize)
+	out.histogram("eventsource_retry_delay_seconds", "Delay before reconnection attempts.", c.retryDelay)
+	return out.n, out.err
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, upper := range h.buckets {
+		if value <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// writer keeps the first error, and the amount of bytes written.
+type writer struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) header(name, help, kind string) {
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (w *writer) counter(name, help string, value uint64) {
+	w.header(name, help, "counter")
+	w.printf("%s %d\n", name, value)
+}
+
+func (w *writer) gauge(name, help string, value int64) {
+	w.header(name, help, "gauge")
+	w.printf("%s %d\n", name, value)
+}
+
+func (w *writer) histogram(name, help string, h *histogram) {
+	w.header(name, help, "histogram")
+	for i, upper := range h.buckets {
+		w.printf("%s_bucket{le=\"%s\"} %d\n", name, formatFloat(upper), h.counts[i])
+	}
+	w.printf("%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	w.printf("%s_sum %s\n", name, formatFloat(h.sum))
+	w.printf("%s_count %d\n", name, h.count)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
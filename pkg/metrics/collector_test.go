@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alevinval/sse/pkg/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_ServeHTTP(t *testing.T) {
+	sut := NewCollector()
+	sut.OnConnectAttempt("http://foo.com")
+	sut.OnConnected(nil)
+	sut.OnEvent(&base.MessageEvent{Data: "data"}, 100)
+	sut.OnEvent(&base.MessageEvent{Data: "data"}, 2000)
+	sut.OnDisconnected(io.EOF)
+	sut.OnRetryScheduled(2 * time.Second)
+	sut.OnConnectAttempt("http://foo.com")
+	sut.OnClosed(errors.New("closed"))
+
+	rw := httptest.NewRecorder()
+	sut.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, contentType, rw.Header().Get("Content-Type"))
+	body := rw.Body.String()
+	for _, line := range []string{
+		"# TYPE eventsource_connect_attempts_total counter",
+		"eventsource_connect_attempts_total 2",
+		"eventsource_connections_total 1",
+		"eventsource_disconnections_total 1",
+		"eventsource_open_connections 0",
+		"eventsource_closed_total 1",
+		"eventsource_events_total 2",
+		"# TYPE eventsource_event_size_bytes histogram",
+		`eventsource_event_size_bytes_bucket{le="64"} 0`,
+		`eventsource_event_size_bytes_bucket{le="256"} 1`,
+		`eventsource_event_size_bytes_bucket{le="4096"} 2`,
+		`eventsource_event_size_bytes_bucket{le="+Inf"} 2`,
+		"eventsource_event_size_bytes_sum 2100",
+		"eventsource_event_size_bytes_count 2",
+		`eventsource_retry_delay_seconds_bucket{le="1"} 0`,
+		`eventsource_retry_delay_seconds_bucket{le="2.5"} 1`,
+		"eventsource_retry_delay_seconds_sum 2",
+	} {
+		assert.Contains(t, strings.Split(body, "\n"), line)
+	}
+}
+
+func TestCollector_WriteTo_ReturnsBytesWritten(t *testing.T) {
+	sut := NewCollector()
+	out := new(strings.Builder)
+
+	n, err := sut.WriteTo(out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+}
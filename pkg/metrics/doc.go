@@ -0,0 +1,5 @@
+/*
+Metrics package provides an eventsource.Observer that exposes counters and
+histograms in the Prometheus text exposition format.
+*/
+package metrics